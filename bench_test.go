@@ -0,0 +1,88 @@
+package kelly
+
+import (
+	"net/http"
+	"testing"
+)
+
+// benchWriter 可复用的http.ResponseWriter，避免测试本身的内存分配
+type benchWriter struct {
+	header http.Header
+}
+
+func (w *benchWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *benchWriter) Write(data []byte) (int, error) {
+	return len(data), nil
+}
+
+func (w *benchWriter) WriteHeader(int) {}
+
+func newBenchKelly() Kelly {
+	return New(&Config{
+		Logger:        NewTextLogger(discardWriter{}, LevelError),
+		DisableBanner: true,
+	})
+}
+
+type discardWriter struct{}
+
+func (discardWriter) Write(data []byte) (int, error) {
+	return len(data), nil
+}
+
+func runRequest(b *testing.B, k Kelly, method, path string) {
+	r, _ := http.NewRequest(method, path, nil)
+	w := &benchWriter{header: http.Header{}}
+	k.ServeHTTP(w, r)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		k.ServeHTTP(w, r)
+	}
+}
+
+func BenchmarkServeEmpty(b *testing.B) {
+	k := newBenchKelly()
+	k.GET("/", func(c *Context) {})
+	runRequest(b, k, http.MethodGet, "/")
+}
+
+func BenchmarkServeParam(b *testing.B) {
+	k := newBenchKelly()
+	k.GET("/users/:id", func(c *Context) {
+		c.MustGetPathVarible("id")
+	})
+	runRequest(b, k, http.MethodGet, "/users/123")
+}
+
+func BenchmarkServeMiddlewares(b *testing.B) {
+	next := func(c *Context) {
+		c.InvokeNext()
+	}
+	k := newBenchKelly()
+	k.Use(next, next)
+	g := k.Group("/api", next, next)
+	g.GET("/ping", next, func(c *Context) {})
+	runRequest(b, k, http.MethodGet, "/api/ping")
+}
+
+func BenchmarkServeContextData(b *testing.B) {
+	k := newBenchKelly()
+	k.GET("/", func(c *Context) {
+		c.Set("key", "value")
+		c.InvokeNext()
+	}, func(c *Context) {
+		c.MustGet("key")
+	})
+	runRequest(b, k, http.MethodGet, "/")
+}
+
+func BenchmarkServeNotFound(b *testing.B) {
+	k := newBenchKelly()
+	k.GET("/", func(c *Context) {})
+	runRequest(b, k, http.MethodGet, "/404")
+}
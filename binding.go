@@ -1,40 +1,78 @@
 package kelly
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
+	"strings"
 
-	"github.com/julienschmidt/httprouter"
+	"github.com/lixinio/kelly/binding"
 	"github.com/lixinio/kelly/validator"
-	"github.com/mitchellh/mapstructure"
 )
 
 // binderAdapter 绑定输入的适配接口
 type binderAdapter interface {
-	Bind(*http.Request, interface{}) error     // 绑定一个对象，根据Content-type自动判断类型
-	BindJSON(*http.Request, interface{}) error // 绑定json，从body取数据
-	BindXML(*http.Request, interface{}) error  // 绑定xml，从body取数据
-	BindForm(*http.Request, interface{}) error // 绑定form，从body/query取数据
+	Bind(*http.Request, interface{}) error           // 绑定一个对象，根据Content-type自动判断类型
+	BindJSON(*http.Request, interface{}) error       // 绑定json，从body取数据
+	BindXML(*http.Request, interface{}) error        // 绑定xml，从body取数据
+	BindForm(*http.Request, interface{}) error       // 绑定form，从body/query取数据
+	BindQuery(*http.Request, interface{}) error      // 绑定query，只从url取数据
+	BindHeader(*http.Request, interface{}) error     // 绑定请求头
+	BindCookie(*http.Request, interface{}) error     // 绑定cookie
+	BindPath(map[string][]string, interface{}) error // 绑定path变量
+	// 多来源绑定，body tag 的字段交给bindBody
+	BindRequest(*http.Request, map[string][]string, interface{}, func(interface{}) error) []error
+	BindYAML(*http.Request, interface{}) error     // 绑定yaml，从body取数据
+	BindMsgPack(*http.Request, interface{}) error  // 绑定msgpack，从body取数据
+	BindProtoBuf(*http.Request, interface{}) error // 绑定protobuf，从body取数据
 }
 
 type binder interface {
-	Bind(interface{}) error     // 绑定一个对象，根据Content-type自动判断类型
-	BindJSON(interface{}) error // 绑定json，从body取数据
-	BindXML(interface{}) error  // 绑定xml，从body取数据
-	BindForm(interface{}) error // 绑定form，从body/query取数据
-	BindPath(interface{}) error // 绑定path变量
+	Bind(interface{}) error         // 绑定一个对象，根据Content-type自动判断类型
+	BindJSON(interface{}) error     // 绑定json，从body取数据
+	BindXML(interface{}) error      // 绑定xml，从body取数据
+	BindForm(interface{}) error     // 绑定form，从body/query取数据
+	BindPath(interface{}) error     // 绑定path变量
+	BindQuery(interface{}) error    // 绑定query，只从url取数据，使用json tag
+	BindHeader(interface{}) error   // 绑定请求头，使用header tag
+	BindCookie(interface{}) error   // 绑定cookie，使用cookie tag
+	BindAll(interface{}) error      // 多来源绑定，使用 path/query/header/cookie/body tag
+	BindYAML(interface{}) error     // 绑定yaml，从body取数据
+	BindMsgPack(interface{}) error  // 绑定msgpack，从body取数据
+	BindProtoBuf(interface{}) error // 绑定protobuf，从body取数据
 
 	GetBindParameter() interface{}
 	GetBindJSONParameter() interface{}
 	GetBindXMLParameter() interface{}
 	GetBindFormParameter() interface{}
 	GetBindPathParameter() interface{}
+	GetBindQueryParameter() interface{}
+	GetBindHeaderParameter() interface{}
+	GetBindCookieParameter() interface{}
+	GetBindRequestParameter() interface{}
 }
 
 // BindErrorHandle bind失败的错误处理
 type BindErrorHandle func(*Context, error)
 
+// handleBindErr 不支持的Content-Type返回415，body太大返回413，其他错误返回400
 func handleBindErr(c *Context, err error) {
+	if errors.Is(err, ErrUnsupportedMediaType) {
+		c.WriteJSON(http.StatusUnsupportedMediaType, H{
+			"code":  http.StatusUnsupportedMediaType,
+			"error": err.Error(),
+		})
+		return
+	}
+	if errors.Is(err, ErrBodyTooLarge) {
+		// 剩余的body没有读取，不再复用连接
+		c.SetHeader("Connection", "close")
+		c.WriteJSON(http.StatusRequestEntityTooLarge, H{
+			"code":  http.StatusRequestEntityTooLarge,
+			"error": err.Error(),
+		})
+		return
+	}
 	c.WriteJSON(http.StatusBadRequest, H{
 		"code":  http.StatusUnprocessableEntity,
 		"error": err.Error(),
@@ -42,29 +80,62 @@ func handleBindErr(c *Context, err error) {
 }
 
 const (
-	contextBindKey     = "_binder_key"
-	contextBindJSONKey = "_binder_json_key"
-	contextBindXMLKey  = "_binder_xml_key"
-	contextBindFormKey = "_binder_form_key"
-	contextBindPathKey = "_binder_path_key"
+	contextBindKey        = "_binder_key"
+	contextBindJSONKey    = "_binder_json_key"
+	contextBindXMLKey     = "_binder_xml_key"
+	contextBindFormKey    = "_binder_form_key"
+	contextBindPathKey    = "_binder_path_key"
+	contextBindQueryKey   = "_binder_query_key"
+	contextBindHeaderKey  = "_binder_header_key"
+	contextBindCookieKey  = "_binder_cookie_key"
+	contextBindRequestKey = "_binder_request_key"
 )
 
-type binderImp struct {
-	c *Context      // http 请求上下文
-	b binderAdapter // binder实现
+// BindError 多来源绑定（以及校验）的错误汇总，绑定错误为 *binding.FieldError
+type BindError struct {
+	Errors []error
+}
+
+func (e *BindError) Error() string {
+	msgs := make([]string, 0, len(e.Errors))
+	for _, err := range e.Errors {
+		msgs = append(msgs, err.Error())
+	}
+	return ErrBindFail.Error() + ": " + strings.Join(msgs, "; ")
 }
 
-func newBinder(c *Context, b binderAdapter) binder {
-	return &binderImp{
-		c: c,
-		b: b,
+// Is 匹配任意一个错误，eg. errors.Is(err, ErrUnsupportedMediaType)
+func (e *BindError) Is(target error) bool {
+	for _, err := range e.Errors {
+		if errors.Is(err, target) {
+			return true
+		}
 	}
+	return false
+}
+
+func (e *BindError) Unwrap() error {
+	return ErrBindFail
+}
+
+type binderImp struct {
+	c *Context // http 请求上下文
 }
 
 func wrapBindError(message string, err error) error {
 	if err == nil {
 		return nil
 	}
+	// 严格模式下返回所有字段的错误
+	if errs, ok := err.(binding.FieldErrors); ok {
+		return &BindError{Errors: errs}
+	}
+	if errors.Is(err, binding.ErrUnsupportedMediaType) {
+		return fmt.Errorf("bind error(%s), : %w(%s)", message, ErrUnsupportedMediaType, err)
+	}
+	if errors.Is(err, binding.ErrBodyTooLarge) {
+		return fmt.Errorf("bind error(%s), : %w(%s)", message, ErrBodyTooLarge, err)
+	}
 	return fmt.Errorf("bind error(%s), : %w(%s)", message, ErrBindFail, err)
 }
 
@@ -88,44 +159,114 @@ func (b *binderImp) GetBindPathParameter() interface{} {
 	return b.c.MustGet(contextBindPathKey)
 }
 
+func (b *binderImp) GetBindQueryParameter() interface{} {
+	return b.c.MustGet(contextBindQueryKey)
+}
+
+func (b *binderImp) GetBindHeaderParameter() interface{} {
+	return b.c.MustGet(contextBindHeaderKey)
+}
+
+func (b *binderImp) GetBindCookieParameter() interface{} {
+	return b.c.MustGet(contextBindCookieKey)
+}
+
+func (b *binderImp) GetBindRequestParameter() interface{} {
+	return b.c.MustGet(contextBindRequestKey)
+}
+
 func (b *binderImp) Bind(obj interface{}) error {
-	return wrapBindError("bind", b.b.Bind(b.c.Request(), obj))
+	b.c.rewindBody()
+	return wrapBindError("bind", b.c.getBinder().Bind(b.c.Request(), obj))
 }
 
 func (b *binderImp) BindJSON(obj interface{}) error {
-	return wrapBindError("bind json", b.b.BindJSON(b.c.Request(), obj))
+	b.c.rewindBody()
+	return wrapBindError("bind json", b.c.getBinder().BindJSON(b.c.Request(), obj))
 }
 
 func (b *binderImp) BindXML(obj interface{}) error {
-	return wrapBindError("bind xml", b.b.BindXML(b.c.Request(), obj))
+	b.c.rewindBody()
+	return wrapBindError("bind xml", b.c.getBinder().BindXML(b.c.Request(), obj))
 }
 
 func (b *binderImp) BindForm(obj interface{}) error {
-	return wrapBindError("bind form", b.b.BindForm(b.c.Request(), obj))
+	b.c.rewindBody()
+	return wrapBindError("bind form", b.c.getBinder().BindForm(b.c.Request(), obj))
 }
 
-func (b *binderImp) BindPath(obj interface{}) error {
-	params := b.c.Get(contextDataKeyPathVarible).(httprouter.Params)
-	myData := map[string]string{}
-	for _, param := range params {
-		myData[param.Key] = param.Value
+func (b *binderImp) BindQuery(obj interface{}) error {
+	return wrapBindError("bind query", b.c.getBinder().BindQuery(b.c.Request(), obj))
+}
+
+func (b *binderImp) BindHeader(obj interface{}) error {
+	return wrapBindError("bind header", b.c.getBinder().BindHeader(b.c.Request(), obj))
+}
+
+func (b *binderImp) BindCookie(obj interface{}) error {
+	return wrapBindError("bind cookie", b.c.getBinder().BindCookie(b.c.Request(), obj))
+}
+
+// BindAll 按照tag从多个来源绑定到一个结构体 eg. `path:"id"` `query:"page"` `header:"X-Tenant"` `cookie:"sid"`
+// `body:""` 的字段根据Content-Type绑定body；不会在第一个错误时停止，失败时返回 *BindError
+func (b *binderImp) BindAll(obj interface{}) error {
+	errs := b.bindAll(obj)
+	if len(errs) == 0 {
+		return nil
 	}
+	return &BindError{Errors: errs}
+}
 
-	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
-		Metadata:         nil,
-		Result:           obj,
-		TagName:          "json",
-		WeaklyTypedInput: true,
+func (b *binderImp) bindAll(obj interface{}) []error {
+	return b.c.getBinder().BindRequest(b.c.Request(), b.pathValues(), obj, func(body interface{}) error {
+		b.c.rewindBody()
+		return wrapBindError("bind body", b.c.getBinder().Bind(b.c.Request(), body))
 	})
-	if err != nil {
-		return wrapBindError("bind path: mapstructure new decoder", err)
+}
+
+func (b *binderImp) BindYAML(obj interface{}) error {
+	b.c.rewindBody()
+	return wrapBindError("bind yaml", b.c.getBinder().BindYAML(b.c.Request(), obj))
+}
+
+func (b *binderImp) BindMsgPack(obj interface{}) error {
+	b.c.rewindBody()
+	return wrapBindError("bind msgpack", b.c.getBinder().BindMsgPack(b.c.Request(), obj))
+}
+
+func (b *binderImp) BindProtoBuf(obj interface{}) error {
+	b.c.rewindBody()
+	return wrapBindError("bind protobuf", b.c.getBinder().BindProtoBuf(b.c.Request(), obj))
+}
+
+func (b *binderImp) BindPath(obj interface{}) error {
+	return wrapBindError("bind path", b.c.getBinder().BindPath(b.pathValues(), obj))
+}
+
+func (b *binderImp) pathValues() map[string][]string {
+	path := make(map[string][]string, len(b.c.params))
+	for _, param := range b.c.params {
+		path[param.Key] = append(path[param.Key], param.Value)
 	}
+	return path
+}
 
-	return wrapBindError("bind path: mapstructure decode", decoder.Decode(myData))
+// binderKey Config.Binder 在容器中的key，可以使用 BinderMiddleware 在请求级别覆盖
+var binderKey = NewKey[*binding.Binder]("kelly.binder")
+
+// BinderMiddleware 之后的handler使用binder绑定参数，用于单独配置某些路由 eg. 上传接口放宽body的限制
+// eg. kelly.BinderMiddleware(&binding.Binder{MaxBodySize: 100 << 20}), kelly.BindFormMiddleware(...)
+func BinderMiddleware(binder *binding.Binder) HandlerFunc {
+	return func(c *Context) {
+		c.Set(binderKey, binder)
+		c.InvokeNext()
+	}
 }
 
-// BindMiddleware 绑定query参数中间件
-func BindMiddleware(
+// bindMiddleware 绑定参数（并校验），成功后保存到key，失败时交给errHandler处理
+func bindMiddleware(
+	key string,
+	bind func(*Context, interface{}) error,
 	objG func() interface{},
 	validator validator.Validator,
 	errHandler BindErrorHandle,
@@ -133,58 +274,39 @@ func BindMiddleware(
 	if errHandler == nil {
 		errHandler = handleBindErr
 	}
+
 	return func(c *Context) {
 		obj := objG()
-		err := c.Bind(obj)
-		if err == nil {
-			if validator != nil {
-				err = validator.Validate(obj)
-				if err == nil {
-					c.Set(contextBindKey, obj)
-					c.InvokeNext()
-					return
-				}
-			} else {
-				c.Set(contextBindKey, obj)
-				c.InvokeNext()
-				return
-			}
+		err := bind(c, obj)
+		if err == nil && validator != nil {
+			err = validator.Validate(obj)
+		}
+		if err != nil {
+			errHandler(c, err)
+			return
 		}
 
-		errHandler(c, err)
+		c.Set(key, obj)
+		c.InvokeNext()
 	}
 }
 
+// BindMiddleware 绑定query参数中间件
+func BindMiddleware(
+	objG func() interface{},
+	validator validator.Validator,
+	errHandler BindErrorHandle,
+) HandlerFunc {
+	return bindMiddleware(contextBindKey, (*Context).Bind, objG, validator, errHandler)
+}
+
 // BindJSONMiddleware 绑定json参数中间件
 func BindJSONMiddleware(
 	objG func() interface{},
 	validator validator.Validator,
 	errHandler BindErrorHandle,
 ) HandlerFunc {
-	if errHandler == nil {
-		errHandler = handleBindErr
-	}
-
-	return func(c *Context) {
-		obj := objG()
-		err := c.BindJSON(obj)
-		if err == nil {
-			if validator != nil {
-				err = validator.Validate(obj)
-				if err == nil {
-					c.Set(contextBindJSONKey, obj)
-					c.InvokeNext()
-					return
-				}
-			} else {
-				c.Set(contextBindJSONKey, obj)
-				c.InvokeNext()
-				return
-			}
-		}
-
-		errHandler(c, err)
-	}
+	return bindMiddleware(contextBindJSONKey, (*Context).BindJSON, objG, validator, errHandler)
 }
 
 // BindXMLMiddleware 绑定xml参数中间件
@@ -193,30 +315,7 @@ func BindXMLMiddleware(
 	validator validator.Validator,
 	errHandler BindErrorHandle,
 ) HandlerFunc {
-	if errHandler == nil {
-		errHandler = handleBindErr
-	}
-
-	return func(c *Context) {
-		obj := objG()
-		err := c.BindXML(obj)
-		if err == nil {
-			if validator != nil {
-				err = validator.Validate(obj)
-				if err == nil {
-					c.Set(contextBindXMLKey, obj)
-					c.InvokeNext()
-					return
-				}
-			} else {
-				c.Set(contextBindXMLKey, obj)
-				c.InvokeNext()
-				return
-			}
-		}
-
-		errHandler(c, err)
-	}
+	return bindMiddleware(contextBindXMLKey, (*Context).BindXML, objG, validator, errHandler)
 }
 
 // BindFormMiddleware 绑定form参数中间件
@@ -225,30 +324,7 @@ func BindFormMiddleware(
 	validator validator.Validator,
 	errHandler BindErrorHandle,
 ) HandlerFunc {
-	if errHandler == nil {
-		errHandler = handleBindErr
-	}
-
-	return func(c *Context) {
-		obj := objG()
-		err := c.BindForm(obj)
-		if err == nil {
-			if validator != nil {
-				err = validator.Validate(obj)
-				if err == nil {
-					c.Set(contextBindFormKey, obj)
-					c.InvokeNext()
-					return
-				}
-			} else {
-				c.Set(contextBindFormKey, obj)
-				c.InvokeNext()
-				return
-			}
-		}
-
-		errHandler(c, err)
-	}
+	return bindMiddleware(contextBindFormKey, (*Context).BindForm, objG, validator, errHandler)
 }
 
 // BindPathMiddleware 绑定path参数中间件
@@ -256,6 +332,42 @@ func BindPathMiddleware(
 	objG func() interface{},
 	validator validator.Validator,
 	errHandler BindErrorHandle,
+) HandlerFunc {
+	return bindMiddleware(contextBindPathKey, (*Context).BindPath, objG, validator, errHandler)
+}
+
+// BindQueryMiddleware 绑定url query参数中间件（不包括body）
+func BindQueryMiddleware(
+	objG func() interface{},
+	validator validator.Validator,
+	errHandler BindErrorHandle,
+) HandlerFunc {
+	return bindMiddleware(contextBindQueryKey, (*Context).BindQuery, objG, validator, errHandler)
+}
+
+// BindHeaderMiddleware 绑定请求头中间件
+func BindHeaderMiddleware(
+	objG func() interface{},
+	validator validator.Validator,
+	errHandler BindErrorHandle,
+) HandlerFunc {
+	return bindMiddleware(contextBindHeaderKey, (*Context).BindHeader, objG, validator, errHandler)
+}
+
+// BindCookieMiddleware 绑定cookie中间件
+func BindCookieMiddleware(
+	objG func() interface{},
+	validator validator.Validator,
+	errHandler BindErrorHandle,
+) HandlerFunc {
+	return bindMiddleware(contextBindCookieKey, (*Context).BindCookie, objG, validator, errHandler)
+}
+
+// BindRequestMiddleware 多来源绑定中间件（见 BindAll），绑定和校验的错误一起交给errHandler（*BindError）
+func BindRequestMiddleware(
+	objG func() interface{},
+	validator validator.Validator,
+	errHandler BindErrorHandle,
 ) HandlerFunc {
 	if errHandler == nil {
 		errHandler = handleBindErr
@@ -263,22 +375,18 @@ func BindPathMiddleware(
 
 	return func(c *Context) {
 		obj := objG()
-		err := c.BindPath(obj)
-		if err == nil {
-			if validator != nil {
-				err = validator.Validate(obj)
-				if err == nil {
-					c.Set(contextBindPathKey, obj)
-					c.InvokeNext()
-					return
-				}
-			} else {
-				c.Set(contextBindPathKey, obj)
-				c.InvokeNext()
-				return
+		errs := c.bind.bindAll(obj)
+		if validator != nil {
+			if err := validator.Validate(obj); err != nil {
+				errs = append(errs, err)
 			}
 		}
+		if len(errs) > 0 {
+			errHandler(c, &BindError{Errors: errs})
+			return
+		}
 
-		errHandler(c, err)
+		c.Set(contextBindRequestKey, obj)
+		c.InvokeNext()
 	}
 }
@@ -1,7 +1,12 @@
 package binding
 
 import (
+	"errors"
+	"fmt"
+	"mime"
 	"net/http"
+	"strings"
+	"sync"
 )
 
 const (
@@ -10,6 +15,11 @@ const (
 	MIMEXML               = "application/xml"
 	MIMEXML2              = "text/xml"
 	MIMEPlain             = "text/plain"
+	MIMEYAML              = "application/yaml"
+	MIMEYAML2             = "application/x-yaml"
+	MIMEMSGPACK           = "application/msgpack"
+	MIMEMSGPACK2          = "application/x-msgpack"
+	MIMEPROTOBUF          = "application/x-protobuf"
 	MIMEPOSTForm          = "application/x-www-form-urlencoded"
 	MIMEMultipartPOSTForm = "multipart/form-data"
 )
@@ -25,43 +35,198 @@ var (
 	Form          = formBinding{}
 	FormPost      = formPostBinding{}
 	FormMultipart = formMultipartBinding{}
+	YAML          = yamlBinding{}
+	MsgPack       = msgpackBinding{}
+	ProtoBuf      = protobufBinding{}
+	Query         = queryBinding{}
+	Header        = headerBinding{}
+	Cookie        = cookieBinding{}
 )
 
+// ErrUnsupportedMediaType 没有Content-Type对应的Binding
+var ErrUnsupportedMediaType = errors.New("unsupported media type")
+
+// registry mime类型到Binding的映射
+type registry struct {
+	lock     sync.RWMutex
+	bindings map[string]Binding
+}
+
+func (reg *registry) register(mimeType string, b Binding) {
+	reg.lock.Lock()
+	defer reg.lock.Unlock()
+	if reg.bindings == nil {
+		reg.bindings = map[string]Binding{}
+	}
+	reg.bindings[strings.ToLower(mimeType)] = b
+}
+
+func (reg *registry) lookup(mimeType string) (Binding, bool) {
+	reg.lock.RLock()
+	defer reg.lock.RUnlock()
+	b, ok := reg.bindings[mimeType]
+	return b, ok
+}
+
+var bindings = &registry{}
+
+func init() {
+	Register(MIMEJSON, JSON)
+	Register(MIMEXML, XML)
+	Register(MIMEXML2, XML)
+	Register(MIMEYAML, YAML)
+	Register(MIMEYAML2, YAML)
+	Register(MIMEMSGPACK, MsgPack)
+	Register(MIMEMSGPACK2, MsgPack)
+	Register(MIMEPROTOBUF, ProtoBuf)
+	Register(MIMEPOSTForm, Form)
+	Register(MIMEMultipartPOSTForm, Form)
+}
+
+// Register 注册（或者替换）全局的Binding，对所有Binder生效
+func Register(mimeType string, b Binding) {
+	bindings.register(mimeType, b)
+}
+
+// mediaType 去掉Content-Type的参数，eg. charset
+func mediaType(contentType string) string {
+	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil {
+		return mediaType
+	}
+	return strings.ToLower(strings.TrimSpace(contentType))
+}
+
+// Default 根据请求方法和Content-Type（忽略参数，eg. charset）选择Binding
+// GET请求和没有Content-Type的请求使用Form，没有注册的Content-Type返回nil
 func Default(method, contentType string) Binding {
-	if method == "GET" {
+	if method == http.MethodGet || contentType == "" {
 		return Form
-	} else {
-		switch contentType {
-		case MIMEJSON:
-			return JSON
-		case MIMEXML, MIMEXML2:
-			return XML
-		default: //case MIMEPOSTForm, MIMEMultipartPOSTForm:
-			return Form
-		}
 	}
+	b, _ := bindings.lookup(mediaType(contentType))
+	return b
 }
 
+// Binder 在全局的Binding之外，可以单独注册Binding（例如每个Kelly实例使用不同的配置）
 type Binder struct {
+	// Strict form/query/header/cookie/path 的严格模式：空字符串不再视为零值，
+	// 不会在第一个错误时停止，返回所有字段的错误（FieldErrors）
+	Strict bool
+	// JSON json的解码选项
+	JSON JSONOptions
+	// MaxBodySize body的最大字节数，超过时返回 ErrBodyTooLarge，0表示不限制
+	MaxBodySize int64
+	// MaxMultipartMemory multipart表单保存在内存中的最大字节数，超过的部分写入临时文件，缺省 DefaultMaxMultipartMemory
+	MaxMultipartMemory int64
+
+	bindings registry
+}
+
+// configurableBinding 使用Binder的选项（eg. Strict/JSON）的Binding
+type configurableBinding interface {
+	configure(binder *Binder) Binding
+}
+
+func (binder *Binder) bindWith(r *http.Request, obj interface{}, b Binding) error {
+	if cb, ok := b.(configurableBinding); ok {
+		b = cb.configure(binder)
+	}
+	if binder.MaxBodySize <= 0 || r.Body == nil || r.Body == http.NoBody {
+		return b.Bind(r, obj)
+	}
+
+	body := limitBody(r, binder.MaxBodySize)
+	err := b.Bind(r, obj)
+	if err != nil && body.exceeded {
+		return fmt.Errorf("%w: limit %d bytes", ErrBodyTooLarge, binder.MaxBodySize)
+	}
+	return err
+}
+
+// Clone 复制选项和单独注册的Binding，eg. 为某些路由放宽body的限制
+func (binder *Binder) Clone() *Binder {
+	clone := &Binder{
+		Strict:             binder.Strict,
+		JSON:               binder.JSON,
+		MaxBodySize:        binder.MaxBodySize,
+		MaxMultipartMemory: binder.MaxMultipartMemory,
+	}
+	binder.bindings.lock.RLock()
+	defer binder.bindings.lock.RUnlock()
+	for mimeType, b := range binder.bindings.bindings {
+		clone.bindings.register(mimeType, b)
+	}
+	return clone
+}
+
+// Register 注册（或者替换）Binding，只对当前Binder生效，优先于全局的Binding
+func (binder *Binder) Register(mimeType string, b Binding) *Binder {
+	binder.bindings.register(mimeType, b)
+	return binder
 }
 
-func bindWith(r *http.Request, obj interface{}, b Binding) error {
-	return b.Bind(r, obj)
+// Lookup 获得请求对应的Binding，没有时返回 ErrUnsupportedMediaType
+func (binder *Binder) Lookup(r *http.Request) (Binding, error) {
+	contentType := r.Header.Get("Content-Type")
+	if r.Method != http.MethodGet && contentType != "" {
+		if b, ok := binder.bindings.lookup(mediaType(contentType)); ok {
+			return b, nil
+		}
+	}
+	if b := Default(r.Method, contentType); b != nil {
+		return b, nil
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnsupportedMediaType, contentType)
 }
 
 func (binder *Binder) Bind(r *http.Request, obj interface{}) error {
-	header := r.Header.Get("Content-Type")
-	bind := Default(r.Method, header)
-	return bindWith(r, obj, bind)
+	b, err := binder.Lookup(r)
+	if err != nil {
+		return err
+	}
+	return binder.bindWith(r, obj, b)
 }
 func (binder *Binder) BindJSON(r *http.Request, obj interface{}) error {
-	return bindWith(r, obj, JSON)
+	return binder.bindWith(r, obj, JSON)
 }
 func (binder *Binder) BindXML(r *http.Request, obj interface{}) error {
-	return bindWith(r, obj, XML)
+	return binder.bindWith(r, obj, XML)
 }
 func (binder *Binder) BindForm(r *http.Request, obj interface{}) error {
-	return bindWith(r, obj, Form)
+	return binder.bindWith(r, obj, Form)
+}
+func (binder *Binder) BindQuery(r *http.Request, obj interface{}) error {
+	return binder.bindWith(r, obj, Query)
+}
+func (binder *Binder) BindHeader(r *http.Request, obj interface{}) error {
+	return binder.bindWith(r, obj, Header)
+}
+func (binder *Binder) BindCookie(r *http.Request, obj interface{}) error {
+	return binder.bindWith(r, obj, Cookie)
+}
+func (binder *Binder) BindYAML(r *http.Request, obj interface{}) error {
+	return binder.bindWith(r, obj, YAML)
+}
+func (binder *Binder) BindMsgPack(r *http.Request, obj interface{}) error {
+	return binder.bindWith(r, obj, MsgPack)
+}
+func (binder *Binder) BindProtoBuf(r *http.Request, obj interface{}) error {
+	return binder.bindWith(r, obj, ProtoBuf)
+}
+
+// BindPath 使用json tag绑定path变量，没有tag时使用字段名，均不区分大小写
+func (binder *Binder) BindPath(params map[string][]string, obj interface{}) error {
+	return mapPath(obj, params, binder.Strict)
+}
+
+// BindRequest 按照 path/query/header/cookie tag 从请求的对应来源绑定字段，body tag 的字段交给bindBody
+// 没有来源tag的结构体字段递归处理；遇到错误不会停止，返回所有字段的错误（*FieldError）
+func (binder *Binder) BindRequest(
+	r *http.Request,
+	params map[string][]string,
+	obj interface{},
+	bindBody func(interface{}) error,
+) []error {
+	return mapRequest(obj, r, params, bindBody, binder.Strict)
 }
 
 func NewBinder() *Binder {
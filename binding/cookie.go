@@ -0,0 +1,38 @@
+package binding
+
+import (
+	"net/http"
+	"net/url"
+)
+
+type cookieBinding struct{ strict bool }
+
+func (cookieBinding) configure(binder *Binder) Binding {
+	return cookieBinding{strict: binder.Strict}
+}
+
+func (cookieBinding) Name() string {
+	return "cookie"
+}
+
+// Bind 绑定cookie，使用cookie tag eg. `cookie:"session_id"`
+// cookie的值使用 url.QueryUnescape 解码，同 Context.SetCookie/GetCookie
+func (b cookieBinding) Bind(r *http.Request, obj interface{}) error {
+	cookies, err := cookieValues(r)
+	if err != nil {
+		return err
+	}
+	return mapping(obj, &formMapper{source: cookies, tag: "cookie", name: "cookie", strict: b.strict})
+}
+
+func cookieValues(r *http.Request) (formValues, error) {
+	cookies := formValues{}
+	for _, cookie := range r.Cookies() {
+		value, err := url.QueryUnescape(cookie.Value)
+		if err != nil {
+			return nil, err
+		}
+		cookies[cookie.Name] = append(cookies[cookie.Name], value)
+	}
+	return cookies, nil
+}
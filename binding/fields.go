@@ -0,0 +1,50 @@
+package binding
+
+import (
+	"reflect"
+	"sort"
+)
+
+// FieldSet 内嵌到结构体中，记录绑定时请求中出现的key（不包括default tag的缺省值），用于区分没有传和零值
+// eg. struct { binding.FieldSet; Page int `json:"page"` }，绑定后 obj.Has("page")
+type FieldSet struct {
+	fields map[string]struct{}
+}
+
+// Has 请求中是否有key（同tag，没有tag时为字段名）
+func (s *FieldSet) Has(key string) bool {
+	_, ok := s.fields[key]
+	return ok
+}
+
+// Fields 请求中出现的所有key，已经排序
+func (s *FieldSet) Fields() []string {
+	fields := make([]string, 0, len(s.fields))
+	for field := range s.fields {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+func (s *FieldSet) markField(key string) {
+	if s.fields == nil {
+		s.fields = map[string]struct{}{}
+	}
+	s.fields[key] = struct{}{}
+}
+
+// fieldMarker 内嵌了 FieldSet 的结构体
+type fieldMarker interface {
+	markField(key string)
+}
+
+// markField 若结构体内嵌了 FieldSet，记录请求中出现的key
+func markField(val reflect.Value, key string) {
+	if !val.CanAddr() {
+		return
+	}
+	if marker, ok := val.Addr().Interface().(fieldMarker); ok {
+		marker.markField(key)
+	}
+}
@@ -8,20 +8,54 @@ import (
 	"net/http"
 )
 
-type formBinding struct{}
-type formPostBinding struct{}
-type formMultipartBinding struct{}
+// DefaultMaxMultipartMemory multipart表单保存在内存中的缺省最大字节数
+const DefaultMaxMultipartMemory = 32 << 20 // 32 MB
+
+// formOptions form绑定的选项，来自Binder
+type formOptions struct {
+	strict    bool
+	maxMemory int64
+}
+
+func newFormOptions(binder *Binder) formOptions {
+	return formOptions{strict: binder.Strict, maxMemory: binder.MaxMultipartMemory}
+}
+
+func (opts formOptions) maxMultipartMemory() int64 {
+	if opts.maxMemory <= 0 {
+		return DefaultMaxMultipartMemory
+	}
+	return opts.maxMemory
+}
+
+type formBinding struct{ formOptions }
+type formPostBinding struct{ formOptions }
+type formMultipartBinding struct{ formOptions }
+
+func (formBinding) configure(binder *Binder) Binding {
+	return formBinding{newFormOptions(binder)}
+}
+
+func (formPostBinding) configure(binder *Binder) Binding {
+	return formPostBinding{newFormOptions(binder)}
+}
+
+func (formMultipartBinding) configure(binder *Binder) Binding {
+	return formMultipartBinding{newFormOptions(binder)}
+}
 
 func (formBinding) Name() string {
 	return "form"
 }
 
-func (formBinding) Bind(r *http.Request, obj interface{}) error {
+func (b formBinding) Bind(r *http.Request, obj interface{}) error {
 	if err := r.ParseForm(); err != nil {
 		return err
 	}
-	r.ParseMultipartForm(32 << 10) // 32 MB
-	if err := mapForm(obj, r.Form); err != nil {
+	if err := r.ParseMultipartForm(b.maxMultipartMemory()); err != nil && err != http.ErrNotMultipart {
+		return err
+	}
+	if err := mapForm(obj, r.Form, "form", b.strict); err != nil {
 		return err
 	}
 	return nil
@@ -31,11 +65,11 @@ func (formPostBinding) Name() string {
 	return "form-urlencoded"
 }
 
-func (formPostBinding) Bind(req *http.Request, obj interface{}) error {
+func (b formPostBinding) Bind(req *http.Request, obj interface{}) error {
 	if err := req.ParseForm(); err != nil {
 		return err
 	}
-	if err := mapForm(obj, req.PostForm); err != nil {
+	if err := mapForm(obj, req.PostForm, "form", b.strict); err != nil {
 		return err
 	}
 	return nil
@@ -45,11 +79,11 @@ func (formMultipartBinding) Name() string {
 	return "multipart/form-data"
 }
 
-func (formMultipartBinding) Bind(req *http.Request, obj interface{}) error {
-	if err := req.ParseMultipartForm(32 << 10); err != nil {
+func (b formMultipartBinding) Bind(req *http.Request, obj interface{}) error {
+	if err := req.ParseMultipartForm(b.maxMultipartMemory()); err != nil {
 		return err
 	}
-	if err := mapForm(obj, req.MultipartForm.Value); err != nil {
+	if err := mapForm(obj, req.MultipartForm.Value, "form", b.strict); err != nil {
 		return err
 	}
 	return nil
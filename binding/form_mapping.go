@@ -5,70 +5,401 @@
 package binding
 
 import (
+	"errors"
+	"net/http"
 	"reflect"
+	"sort"
 	"strconv"
 	"strings"
 )
 
-func mapForm(ptr interface{}, form map[string][]string) error {
-	typ := reflect.TypeOf(ptr).Elem()
-	val := reflect.ValueOf(ptr).Elem()
+const (
+	// maxSliceIndex items[n].name 中n的上限，避免恶意请求分配过大的slice
+	maxSliceIndex = 1000
+	// maxMappingDepth 嵌套结构体的最大层数，避免自引用的结构体无限递归
+	maxMappingDepth = 32
+)
+
+var (
+	// ErrSliceIndexOutOfRange items[n] 中的n超过上限
+	ErrSliceIndexOutOfRange = errors.New("form slice index out of range")
+	// ErrEmptyValue 严格模式下空字符串不能转换为零值
+	ErrEmptyValue = errors.New("form value is empty")
+)
+
+// formSource 绑定的数据来源
+type formSource interface {
+	lookup(key string) ([]string, bool)
+	// children 以 key. 开头的所有key的下一级名称，已经排序
+	children(key string) []string
+}
+
+// formValues form/query参数，key中的 [x] 统一为 .x eg. filter[status] => filter.status, items[0][name] => items.0.name
+type formValues map[string][]string
+
+// keyReplacer 按顺序匹配，tags[] => tags, a[b][c] => a.b.c
+var keyReplacer = strings.NewReplacer("[]", "", "][", ".", "[", ".", "]", "")
+
+func newFormValues(form map[string][]string) formValues {
+	normalized := form
+	for key := range form {
+		if strings.Contains(key, "[") {
+			normalized = nil
+			break
+		}
+	}
+	if normalized != nil {
+		return formValues(form)
+	}
+
+	normalized = make(map[string][]string, len(form))
+	for key, values := range form {
+		key = keyReplacer.Replace(key)
+		normalized[key] = append(normalized[key], values...)
+	}
+	return formValues(normalized)
+}
+
+func (form formValues) lookup(key string) ([]string, bool) {
+	values, ok := form[key]
+	return values, ok
+}
+
+func (form formValues) children(key string) []string {
+	prefix := key + "."
+	var names []string
+	seen := map[string]bool{}
+	for k := range form {
+		if !strings.HasPrefix(k, prefix) {
+			continue
+		}
+		name := k[len(prefix):]
+		if i := strings.IndexByte(name, '.'); i >= 0 {
+			name = name[:i]
+		}
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// headerValues 请求头，key不区分大小写，不支持嵌套
+type headerValues http.Header
+
+func (header headerValues) lookup(key string) ([]string, bool) {
+	values, ok := header[http.CanonicalHeaderKey(key)]
+	return values, ok
+}
+
+func (header headerValues) children(key string) []string {
+	return nil
+}
+
+// pathValues path变量，key不区分大小写（兼容之前mapstructure的行为），不支持嵌套
+type pathValues map[string][]string
+
+func (path pathValues) lookup(key string) ([]string, bool) {
+	if values, ok := path[key]; ok {
+		return values, true
+	}
+	for k, values := range path {
+		if strings.EqualFold(k, key) {
+			return values, true
+		}
+	}
+	return nil, false
+}
+
+func (path pathValues) children(key string) []string {
+	return nil
+}
+
+// mapForm 使用json tag绑定form/query变量，name为来源名称
+func mapForm(ptr interface{}, form map[string][]string, name string, strict bool) error {
+	return mapping(ptr, &formMapper{source: newFormValues(form), tag: "json", name: name, strict: strict})
+}
+
+// mapPath 使用json tag绑定path变量，没有tag时使用字段名，均不区分大小写
+func mapPath(ptr interface{}, params map[string][]string, strict bool) error {
+	return mapping(ptr, &formMapper{source: pathValues(params), tag: "json", name: "path", strict: strict})
+}
+
+// mapping 按照tag（没有tag时使用字段名）从source取值，绑定到结构体
+// 严格模式下返回所有字段的错误（FieldErrors），否则返回第一个错误
+func mapping(ptr interface{}, m *formMapper) error {
+	_, err := m.mapStruct(reflect.ValueOf(ptr).Elem(), "", 0)
+	if err != nil {
+		return err
+	}
+	if len(m.errs) > 0 {
+		return m.errs
+	}
+	return nil
+}
+
+// FieldErrors 严格模式下所有字段的错误，每个都是 *FieldError
+type FieldErrors []error
+
+func (errs FieldErrors) Error() string {
+	msgs := make([]string, 0, len(errs))
+	for _, err := range errs {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// formMapper 按照tag从source绑定，嵌套字段的key为 父级key.字段key
+type formMapper struct {
+	source formSource
+	tag    string
+	name   string // 来源名称，用于 FieldError eg. form/query/header
+	strict bool   // 严格模式：空字符串不视为零值，遇到错误继续绑定其他字段
+	errs   FieldErrors
+}
+
+// fieldName 获得字段的key，没有tag时使用字段名
+func (m *formMapper) fieldName(field reflect.StructField) (string, bool) {
+	name, ok := field.Tag.Lookup(m.tag)
+	name = strings.Split(name, ",")[0]
+	if name == "" {
+		return field.Name, false
+	}
+	return name, ok
+}
+
+// mapStruct 绑定结构体的字段，返回是否绑定了任意字段
+func (m *formMapper) mapStruct(val reflect.Value, prefix string, depth int) (bool, error) {
+	if depth > maxMappingDepth {
+		return false, nil
+	}
+
+	set := false
+	typ := val.Type()
 	for i := 0; i < typ.NumField(); i++ {
 		typeField := typ.Field(i)
 		structField := val.Field(i)
 		if !structField.CanSet() {
+			// 内嵌的非导出结构体，导出的字段仍然可以设置
+			if typeField.Anonymous && structField.Kind() == reflect.Struct {
+				ok, err := m.mapStruct(structField, prefix, depth+1)
+				if err != nil {
+					return set, err
+				}
+				set = set || ok
+			}
 			continue
 		}
 
-		structFieldKind := structField.Kind()
-		inputFieldName := typeField.Tag.Get("json")
-		if inputFieldName == "" {
-			inputFieldName = typeField.Name
+		name, tagged := m.fieldName(typeField)
+		if name == "-" {
+			continue
+		}
 
-			// if "form" tag is nil, we inspect if the field is a struct.
+		var ok bool
+		var err error
+		key := prefix + name
+		if !tagged && isNestedStruct(typeField.Type) {
+			// 没有tag的结构体（包括内嵌的结构体）平铺
 			// this would not make sense for JSON parsing but it does for a form
 			// since data is flatten
-			if structFieldKind == reflect.Struct {
-				err := mapForm(structField.Addr().Interface(), form)
-				if err != nil {
-					return err
-				}
-				continue
+			ok, err = mapNested(structField, func(v reflect.Value) (bool, error) {
+				return m.mapStruct(v, prefix, depth+1)
+			})
+		} else {
+			ok, err = m.mapField(typeField, structField, key, depth)
+			if ok {
+				markField(val, name)
 			}
 		}
-		if strings.Contains(inputFieldName, ",") {
-			inputFieldName = strings.Split(inputFieldName, ",")[0]
+		if err != nil {
+			// 嵌套结构体中的错误已经包装过
+			if _, wrapped := err.(*FieldError); !wrapped {
+				err = &FieldError{Source: m.name, Field: typeField.Name, Key: key, Err: err}
+			}
+			if !m.strict {
+				return set, err
+			}
+			m.errs = append(m.errs, err)
+			continue
 		}
-		inputValue, exists := form[inputFieldName]
-		if !exists {
+		set = set || ok
+	}
+	return set, nil
+}
+
+// mapField 绑定key对应的字段，没有key时尝试 key.xxx 形式的嵌套结构体/map/slice
+// 请求中没有时使用default tag，返回值表示请求中是否有这个字段
+func (m *formMapper) mapField(typeField reflect.StructField, field reflect.Value, key string, depth int) (bool, error) {
+	if values, ok := m.source.lookup(key); ok {
+		return true, m.setField(typeField, field, values)
+	}
+
+	ok, err := m.mapChildren(typeField, field, key, depth)
+	if ok || err != nil {
+		return ok, err
+	}
+	if value, exists := typeField.Tag.Lookup("default"); exists {
+		return false, m.setField(typeField, field, []string{value})
+	}
+	return false, nil
+}
+
+func (m *formMapper) mapChildren(typeField reflect.StructField, field reflect.Value, key string, depth int) (bool, error) {
+	children := m.source.children(key)
+	if len(children) == 0 {
+		return false, nil
+	}
+	switch {
+	case isNestedStruct(field.Type()):
+		return mapNested(field, func(v reflect.Value) (bool, error) {
+			return m.mapStruct(v, key+".", depth+1)
+		})
+	case field.Kind() == reflect.Map:
+		return m.mapMap(typeField, field, key, children)
+	case field.Kind() == reflect.Slice:
+		return m.mapSlice(typeField, field, key, children, depth)
+	}
+	return false, nil
+}
+
+// mapMap filter[status]=x 绑定到 map[string]T
+func (m *formMapper) mapMap(typeField reflect.StructField, field reflect.Value, key string, children []string) (bool, error) {
+	if field.IsNil() {
+		field.Set(reflect.MakeMap(field.Type()))
+	}
+
+	set := false
+	for _, child := range children {
+		values, ok := m.source.lookup(key + "." + child)
+		if !ok {
 			continue
 		}
+		k := reflect.New(field.Type().Key()).Elem()
+		if err := m.setValue(child, k, typeField); err != nil {
+			return set, err
+		}
+		v := reflect.New(field.Type().Elem()).Elem()
+		if err := m.setField(typeField, v, values); err != nil {
+			return set, err
+		}
+		field.SetMapIndex(k, v)
+		set = true
+	}
+	return set, nil
+}
 
-		if structFieldKind == reflect.Slice && len(inputValue) > 0 {
-			var realValue []string
-			if _, ok := typeField.Tag.Lookup("disable_split"); !ok {
-				for _, value := range inputValue {
-					realValue = append(realValue, strings.Split(value, ",")...)
-				}
-			} else {
-				realValue = inputValue
+// mapSlice items[0].name=y 或者 tags[0]=a 绑定到slice
+func (m *formMapper) mapSlice(typeField reflect.StructField, field reflect.Value, key string, children []string, depth int) (bool, error) {
+	indexes := make([]int, 0, len(children))
+	size := 0
+	for _, child := range children {
+		index, err := strconv.Atoi(child)
+		if err != nil || index < 0 {
+			continue
+		}
+		if index >= maxSliceIndex {
+			return false, ErrSliceIndexOutOfRange
+		}
+		indexes = append(indexes, index)
+		if index >= size {
+			size = index + 1
+		}
+	}
+	if len(indexes) == 0 {
+		return false, nil
+	}
+
+	slice := reflect.MakeSlice(field.Type(), size, size)
+	for _, index := range indexes {
+		elemKey := key + "." + strconv.Itoa(index)
+		elem := slice.Index(index)
+		if values, ok := m.source.lookup(elemKey); ok {
+			if err := m.setValue(values[0], elem, typeField); err != nil {
+				return false, err
 			}
-			numElems := len(realValue)
-			sliceOf := structField.Type().Elem()
-			slice := reflect.MakeSlice(structField.Type(), numElems, numElems)
-			for i := 0; i < numElems; i++ {
-				if err := setWithProperType(sliceOf, realValue[i], slice.Index(i)); err != nil {
-					return err
-				}
+		} else if isNestedStruct(elem.Type()) {
+			if _, err := mapNested(elem, func(v reflect.Value) (bool, error) {
+				return m.mapStruct(v, elemKey+".", depth+1)
+			}); err != nil {
+				return false, err
+			}
+		}
+	}
+	field.Set(slice)
+	return true, nil
+}
+
+// isNestedStruct 结构体或者结构体指针，不包括 time.Time 等可以从字符串转换的类型
+func isNestedStruct(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct || t == timeType {
+		return false
+	}
+	return !reflect.PtrTo(t).Implements(textUnmarshalerType)
+}
+
+// mapNested 绑定嵌套的结构体，指针为nil时只有绑定了字段才分配
+func mapNested(field reflect.Value, fn func(reflect.Value) (bool, error)) (bool, error) {
+	if field.Kind() != reflect.Ptr {
+		return fn(field)
+	}
+	if !field.IsNil() {
+		return fn(field.Elem())
+	}
+
+	value := reflect.New(field.Type().Elem())
+	ok, err := fn(value.Elem())
+	if ok {
+		field.Set(value)
+	}
+	return ok, err
+}
+
+// setField 绑定一个字段，slice字段缺省按逗号切分（disable_split tag关闭）
+func (m *formMapper) setField(typeField reflect.StructField, structField reflect.Value, inputValue []string) error {
+	if structField.Kind() == reflect.Slice && len(inputValue) > 0 {
+		var realValue []string
+		if _, ok := typeField.Tag.Lookup("disable_split"); !ok {
+			for _, value := range inputValue {
+				realValue = append(realValue, strings.Split(value, ",")...)
 			}
-			val.Field(i).Set(slice)
 		} else {
-			if err := setWithProperType(typeField.Type, inputValue[0], structField); err != nil {
+			realValue = inputValue
+		}
+		numElems := len(realValue)
+		slice := reflect.MakeSlice(structField.Type(), numElems, numElems)
+		for i := 0; i < numElems; i++ {
+			if err := m.setValue(realValue[i], slice.Index(i), typeField); err != nil {
 				return err
 			}
 		}
+		structField.Set(slice)
+		return nil
 	}
-	return nil
+	return m.setValue(inputValue[0], structField, typeField)
+}
+
+// setValue 严格模式下，空字符串只能绑定到字符串或者 encoding.TextUnmarshaler
+func (m *formMapper) setValue(val string, value reflect.Value, field reflect.StructField) error {
+	if m.strict && val == "" && !acceptEmpty(value.Type()) {
+		return ErrEmptyValue
+	}
+	return setWithProperType(val, value, field)
+}
+
+func acceptEmpty(t reflect.Type) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() == reflect.String {
+		return true
+	}
+	return t != timeType && t != durationType && reflect.PtrTo(t).Implements(textUnmarshalerType)
 }
 
 func setIntField(val string, bitSize int, field reflect.Value) error {
@@ -77,11 +408,7 @@ func setIntField(val string, bitSize int, field reflect.Value) error {
 	}
 	intVal, err := strconv.ParseInt(val, 10, bitSize)
 	if err == nil {
-		if field.Type().Kind() != reflect.Ptr {
-			field.SetInt(intVal)
-		} else {
-			field.Set(reflect.ValueOf(&intVal))
-		}
+		field.SetInt(intVal)
 	}
 	return err
 }
@@ -92,11 +419,7 @@ func setUintField(val string, bitSize int, field reflect.Value) error {
 	}
 	uintVal, err := strconv.ParseUint(val, 10, bitSize)
 	if err == nil {
-		if field.Type().Kind() != reflect.Ptr {
-			field.SetUint(uintVal)
-		} else {
-			field.Set(reflect.ValueOf(&uintVal))
-		}
+		field.SetUint(uintVal)
 	}
 	return err
 }
@@ -107,13 +430,9 @@ func setBoolField(val string, field reflect.Value) error {
 	}
 	boolVal, err := strconv.ParseBool(val)
 	if err == nil {
-		if field.Type().Kind() != reflect.Ptr {
-			field.SetBool(boolVal)
-		} else {
-			field.Set(reflect.ValueOf(&boolVal))
-		}
+		field.SetBool(boolVal)
 	}
-	return nil
+	return err
 }
 
 func setFloatField(val string, bitSize int, field reflect.Value) error {
@@ -122,16 +441,7 @@ func setFloatField(val string, bitSize int, field reflect.Value) error {
 	}
 	floatVal, err := strconv.ParseFloat(val, bitSize)
 	if err == nil {
-		if field.Type().Kind() != reflect.Ptr {
-			field.SetFloat(floatVal)
-		} else {
-			if bitSize == 64 {
-				field.Set(reflect.ValueOf(&floatVal))
-			} else {
-				float32Val := float32(floatVal)
-				field.Set(reflect.ValueOf(&float32Val))
-			}
-		}
+		field.SetFloat(floatVal)
 	}
 	return err
 }
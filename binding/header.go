@@ -0,0 +1,20 @@
+package binding
+
+import (
+	"net/http"
+)
+
+type headerBinding struct{ strict bool }
+
+func (headerBinding) configure(binder *Binder) Binding {
+	return headerBinding{strict: binder.Strict}
+}
+
+func (headerBinding) Name() string {
+	return "header"
+}
+
+// Bind 绑定请求头，使用header tag，不区分大小写 eg. `header:"X-Request-Id"`
+func (b headerBinding) Bind(r *http.Request, obj interface{}) error {
+	return mapping(obj, &formMapper{source: headerValues(r.Header), tag: "header", name: "header", strict: b.strict})
+}
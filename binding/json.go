@@ -6,19 +6,52 @@ package binding
 
 import (
 	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
 )
 
-type jsonBinding struct{}
+// ErrTrailingData json之后还有其他数据
+var ErrTrailingData = errors.New("unexpected data after json value")
+
+// JSONOptions json的解码选项
+type JSONOptions struct {
+	// DisallowUnknownFields json中有结构体没有的字段时报错
+	DisallowUnknownFields bool
+	// UseNumber 数字解码为 json.Number（而不是float64），绑定到interface{}时避免丢失精度
+	UseNumber bool
+	// DisallowTrailingData json之后还有其他数据（例如多个json）时报错，缺省忽略之后的数据（兼容之前的行为）
+	DisallowTrailingData bool
+}
+
+type jsonBinding struct {
+	JSONOptions
+}
+
+func (jsonBinding) configure(binder *Binder) Binding {
+	return jsonBinding{binder.JSON}
+}
 
 func (jsonBinding) Name() string {
 	return "json"
 }
 
-func (jsonBinding) Bind(r *http.Request, obj interface{}) error {
+func (b jsonBinding) Bind(r *http.Request, obj interface{}) error {
 	decoder := json.NewDecoder(r.Body)
+	if b.DisallowUnknownFields {
+		decoder.DisallowUnknownFields()
+	}
+	if b.UseNumber {
+		decoder.UseNumber()
+	}
 	if err := decoder.Decode(obj); err != nil {
 		return err
 	}
+	if b.DisallowTrailingData {
+		// 只允许空白字符
+		if _, err := decoder.Token(); err != io.EOF {
+			return ErrTrailingData
+		}
+	}
 	return nil
 }
@@ -0,0 +1,40 @@
+package binding
+
+import (
+	"errors"
+	"io"
+	"net/http"
+)
+
+// ErrBodyTooLarge body超过了 Binder.MaxBodySize
+var ErrBodyTooLarge = errors.New("request body too large")
+
+// maxBytesBody 使用 http.MaxBytesReader 限制body的大小，并记录是否超过了限制
+type maxBytesBody struct {
+	io.ReadCloser
+	limit    int64
+	read     int64
+	exceeded bool
+}
+
+func (b *maxBytesBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.read += int64(n)
+	if err != nil && err != io.EOF && b.read >= b.limit {
+		b.exceeded = true
+	}
+	return n, err
+}
+
+// limitBody 替换请求的body，多次绑定时复用
+func limitBody(r *http.Request, limit int64) *maxBytesBody {
+	if body, ok := r.Body.(*maxBytesBody); ok && body.limit == limit {
+		return body
+	}
+	body := &maxBytesBody{
+		ReadCloser: http.MaxBytesReader(nil, r.Body, limit),
+		limit:      limit,
+	}
+	r.Body = body
+	return body
+}
@@ -0,0 +1,23 @@
+package binding
+
+import (
+	"net/http"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+type msgpackBinding struct{}
+
+func (msgpackBinding) Name() string {
+	return "msgpack"
+}
+
+// Bind 没有msgpack tag的字段使用json tag，和json共用结构体定义
+func (msgpackBinding) Bind(r *http.Request, obj interface{}) error {
+	decoder := msgpack.NewDecoder(r.Body)
+	decoder.SetCustomStructTag("json")
+	if err := decoder.Decode(obj); err != nil {
+		return err
+	}
+	return nil
+}
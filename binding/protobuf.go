@@ -0,0 +1,30 @@
+package binding
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// ErrNotProtoMessage 绑定protobuf的对象必须实现 proto.Message
+var ErrNotProtoMessage = errors.New("object is not a proto.Message")
+
+type protobufBinding struct{}
+
+func (protobufBinding) Name() string {
+	return "protobuf"
+}
+
+func (protobufBinding) Bind(r *http.Request, obj interface{}) error {
+	msg, ok := obj.(proto.Message)
+	if !ok {
+		return ErrNotProtoMessage
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return err
+	}
+	return proto.Unmarshal(body, msg)
+}
@@ -0,0 +1,20 @@
+package binding
+
+import (
+	"net/http"
+)
+
+type queryBinding struct{ strict bool }
+
+func (queryBinding) configure(binder *Binder) Binding {
+	return queryBinding{strict: binder.Strict}
+}
+
+func (queryBinding) Name() string {
+	return "query"
+}
+
+// Bind 只绑定url中的query参数，使用json tag（同form）
+func (b queryBinding) Bind(r *http.Request, obj interface{}) error {
+	return mapForm(obj, r.URL.Query(), "query", b.strict)
+}
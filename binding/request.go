@@ -0,0 +1,132 @@
+package binding
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+const (
+	// BodyTag 使用body绑定的字段 eg. `body:""`，字段为结构体或者结构体指针
+	BodyTag = "body"
+)
+
+// requestTags 按顺序检查的来源tag，一个字段只使用第一个
+var requestTags = []string{"path", "query", "header", "cookie"}
+
+// ErrMultipleBodyFields 结构体中有多个body tag的字段，body只能读取一次
+var ErrMultipleBodyFields = errors.New("only one field can be bound from body")
+
+// FieldError 多来源绑定时某个字段的错误
+type FieldError struct {
+	Source string // path/query/header/cookie/body
+	Field  string // 结构体字段名
+	Key    string // 来源中的key
+	Err    error
+}
+
+func (e *FieldError) Error() string {
+	if e.Key == "" {
+		return fmt.Sprintf("%s(%s): %v", e.Source, e.Field, e.Err)
+	}
+	return fmt.Sprintf("%s %s(%s): %v", e.Source, e.Key, e.Field, e.Err)
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// mapRequest 按照 path/query/header/cookie tag 从请求的对应来源绑定字段，body tag 的字段交给bindBody
+// path变量不区分大小写（同 Binder.BindPath），只能有一个body tag的字段
+// 没有来源tag的结构体字段递归处理；遇到错误不会停止，返回所有字段的错误（*FieldError）
+func mapRequest(ptr interface{}, r *http.Request, path map[string][]string, bindBody func(interface{}) error, strict bool) []error {
+	sources := map[string]formSource{
+		"path":   pathValues(path),
+		"query":  newFormValues(r.URL.Query()),
+		"header": headerValues(r.Header),
+	}
+
+	var errs []error
+	cookies, err := cookieValues(r)
+	if err != nil {
+		errs = append(errs, &FieldError{Source: "cookie", Err: err})
+	}
+	sources["cookie"] = cookies
+
+	mappers := make(map[string]*formMapper, len(sources))
+	for name, source := range sources {
+		mappers[name] = &formMapper{source: source, tag: name, name: name, strict: strict}
+	}
+	bodyBound := false
+	bindOnce := func(obj interface{}) error {
+		if bodyBound {
+			return ErrMultipleBodyFields
+		}
+		bodyBound = true
+		return bindBody(obj)
+	}
+	errs = append(errs, mapRequestStruct(reflect.ValueOf(ptr).Elem(), mappers, bindOnce)...)
+	// 严格模式下嵌套字段的错误
+	for _, tag := range requestTags {
+		errs = append(errs, mappers[tag].errs...)
+	}
+	return errs
+}
+
+func mapRequestStruct(val reflect.Value, mappers map[string]*formMapper, bindBody func(interface{}) error) []error {
+	var errs []error
+	typ := val.Type()
+	for i := 0; i < typ.NumField(); i++ {
+		typeField := typ.Field(i)
+		structField := val.Field(i)
+		if !structField.CanSet() {
+			continue
+		}
+
+		if _, ok := typeField.Tag.Lookup(BodyTag); ok {
+			if err := bindBody(fieldPointer(structField)); err != nil {
+				errs = append(errs, &FieldError{Source: BodyTag, Field: typeField.Name, Err: err})
+			}
+			continue
+		}
+
+		tagged := false
+		for _, tag := range requestTags {
+			key, ok := typeField.Tag.Lookup(tag)
+			if !ok {
+				continue
+			}
+			tagged = true
+			key = strings.Split(key, ",")[0]
+			ok, err := mappers[tag].mapField(typeField, structField, key, 0)
+			if err != nil {
+				if _, wrapped := err.(*FieldError); !wrapped {
+					err = &FieldError{Source: tag, Field: typeField.Name, Key: key, Err: err}
+				}
+				errs = append(errs, err)
+			}
+			if ok {
+				markField(val, key)
+			}
+			break
+		}
+
+		if !tagged && structField.Kind() == reflect.Struct && isNestedStruct(typeField.Type) {
+			errs = append(errs, mapRequestStruct(structField, mappers, bindBody)...)
+		}
+	}
+	return errs
+}
+
+// fieldPointer 获得字段的指针，指针字段为nil时分配
+func fieldPointer(field reflect.Value) interface{} {
+	if field.Kind() == reflect.Ptr {
+		if field.IsNil() {
+			field.Set(reflect.New(field.Type().Elem()))
+		}
+		return field.Interface()
+	}
+	return field.Addr().Interface()
+}
@@ -5,48 +5,135 @@
 package binding
 
 import (
+	"encoding"
 	"errors"
 	"reflect"
+	"strconv"
+	"strings"
+	"time"
 )
 
-func setWithProperType(valueKind reflect.Type, val string, structField reflect.Value) error {
-	switch valueKind.Kind() {
+var (
+	timeType            = reflect.TypeOf(time.Time{})
+	durationType        = reflect.TypeOf(time.Duration(0))
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// setWithProperType 把val转换为value的类型，field用于读取 time_format 等tag
+// 指针类型转换成功后才设置，失败时保持原值（nil仍然表示没有这个参数）
+func setWithProperType(val string, value reflect.Value, field reflect.StructField) error {
+	if value.Kind() == reflect.Ptr {
+		ptr := reflect.New(value.Type().Elem())
+		if err := setWithProperType(val, ptr.Elem(), field); err != nil {
+			return err
+		}
+		value.Set(ptr)
+		return nil
+	}
+
+	switch value.Type() {
+	case timeType:
+		return setTimeField(val, field, value)
+	case durationType:
+		return setDurationField(val, value)
+	}
+	if value.CanAddr() {
+		if unmarshaler, ok := value.Addr().Interface().(encoding.TextUnmarshaler); ok {
+			return unmarshaler.UnmarshalText([]byte(val))
+		}
+	}
+
+	switch value.Kind() {
 	case reflect.Int:
-		return setIntField(val, 0, structField)
+		return setIntField(val, 0, value)
 	case reflect.Int8:
-		return setIntField(val, 8, structField)
+		return setIntField(val, 8, value)
 	case reflect.Int16:
-		return setIntField(val, 16, structField)
+		return setIntField(val, 16, value)
 	case reflect.Int32:
-		return setIntField(val, 32, structField)
+		return setIntField(val, 32, value)
 	case reflect.Int64:
-		return setIntField(val, 64, structField)
+		return setIntField(val, 64, value)
 	case reflect.Uint:
-		return setUintField(val, 0, structField)
+		return setUintField(val, 0, value)
 	case reflect.Uint8:
-		return setUintField(val, 8, structField)
+		return setUintField(val, 8, value)
 	case reflect.Uint16:
-		return setUintField(val, 16, structField)
+		return setUintField(val, 16, value)
 	case reflect.Uint32:
-		return setUintField(val, 32, structField)
+		return setUintField(val, 32, value)
 	case reflect.Uint64:
-		return setUintField(val, 64, structField)
+		return setUintField(val, 64, value)
 	case reflect.Bool:
-		return setBoolField(val, structField)
+		return setBoolField(val, value)
 	case reflect.Float32:
-		return setFloatField(val, 32, structField)
+		return setFloatField(val, 32, value)
 	case reflect.Float64:
-		return setFloatField(val, 64, structField)
+		return setFloatField(val, 64, value)
 	case reflect.String:
-		if structField.Type().Kind() != reflect.Ptr {
-			structField.SetString(val)
-		} else {
-			structField.Set(reflect.ValueOf(&val))
-		}
-	case reflect.Ptr:
-		return setWithProperType(valueKind.Elem(), val, structField)
+		value.SetString(val)
 	default:
 		return errors.New("Unknown type")
 	}
 	return nil
 }
+
+// setTimeField time_format tag指定格式（缺省RFC3339），unix/unixnano表示时间戳
+// time_utc:"true" 或者 time_location:"Asia/Shanghai" 指定没有时区信息时使用的时区，缺省本地时区
+func setTimeField(val string, field reflect.StructField, value reflect.Value) error {
+	if val == "" {
+		value.Set(reflect.ValueOf(time.Time{}))
+		return nil
+	}
+
+	format := field.Tag.Get("time_format")
+	if format == "" {
+		format = time.RFC3339
+	}
+	switch strings.ToLower(format) {
+	case "unix", "unixnano":
+		tv, err := strconv.ParseInt(val, 10, 64)
+		if err != nil {
+			return err
+		}
+		t := time.Unix(tv, 0)
+		if strings.ToLower(format) == "unixnano" {
+			t = time.Unix(0, tv)
+		}
+		value.Set(reflect.ValueOf(t))
+		return nil
+	}
+
+	loc := time.Local
+	if isUTC, _ := strconv.ParseBool(field.Tag.Get("time_utc")); isUTC {
+		loc = time.UTC
+	}
+	if name := field.Tag.Get("time_location"); name != "" {
+		l, err := time.LoadLocation(name)
+		if err != nil {
+			return err
+		}
+		loc = l
+	}
+
+	t, err := time.ParseInLocation(format, val, loc)
+	if err != nil {
+		return err
+	}
+	value.Set(reflect.ValueOf(t))
+	return nil
+}
+
+// setDurationField 格式同 time.ParseDuration eg. 1h30m
+func setDurationField(val string, value reflect.Value) error {
+	if val == "" {
+		value.SetInt(0)
+		return nil
+	}
+	d, err := time.ParseDuration(val)
+	if err != nil {
+		return err
+	}
+	value.SetInt(int64(d))
+	return nil
+}
@@ -0,0 +1,23 @@
+package binding
+
+import (
+	"io"
+	"net/http"
+
+	"gopkg.in/yaml.v3"
+)
+
+type yamlBinding struct{}
+
+func (yamlBinding) Name() string {
+	return "yaml"
+}
+
+func (yamlBinding) Bind(r *http.Request, obj interface{}) error {
+	decoder := yaml.NewDecoder(r.Body)
+	// 空body是合法的空文档，不修改obj
+	if err := decoder.Decode(obj); err != nil && err != io.EOF {
+		return err
+	}
+	return nil
+}
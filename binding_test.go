@@ -1,13 +1,25 @@
 package kelly
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"mime/multipart"
 	"net/http"
+	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/lixinio/kelly/binding"
+	"github.com/vmihailenco/msgpack/v5"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+	"gopkg.in/yaml.v3"
 )
 
 type BindObj struct {
@@ -168,6 +180,23 @@ func TestPathBinding(t *testing.T) {
 	if resp.StatusCode != http.StatusBadRequest {
 		t.Errorf("GetBindPathParameter error %d", resp.StatusCode)
 	}
+
+	// 没有tag的字段使用字段名，path变量名不区分大小写
+	type pathObj struct {
+		ID     int
+		Name   string
+		Active bool `json:"ACTIVE"`
+	}
+	resp = kellyFramwork("/:id/:NAME/:active", "/42/kelly/1", []string{}, func(c *Context) {
+		obj := &pathObj{}
+		if err := c.BindPath(obj); err != nil || !cmp.Equal(obj, &pathObj{ID: 42, Name: "kelly", Active: true}) {
+			t.Errorf("bind path case insensitive fail %v|%v", obj, err)
+		}
+		c.ResponseStatusOK()
+	})
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("bind path case insensitive error %d", resp.StatusCode)
+	}
 }
 
 func TestFormBinding(t *testing.T) {
@@ -292,3 +321,528 @@ func TestBindingValidator(t *testing.T) {
 		t.Errorf("TestBindingValidator error %d", resp.StatusCode)
 	}
 }
+
+func TestCodecBinding(t *testing.T) {
+	obj := &BindObj{A: "b", B: "d", C: 123, D: true}
+	msgpackBody, _ := msgpack.Marshal(map[string]interface{}{
+		"aaa": "b", "bbb": "d", "ccc": 123, "ddd": true,
+	})
+	protoBody, _ := proto.Marshal(wrapperspb.String("kelly"))
+
+	// 自动选择Binding，并按照同样的格式输出
+	echo := func(contentType string, body []byte, result interface{}) *httptest.ResponseRecorder {
+		r, _ := http.NewRequest(http.MethodPost, "/", bytes.NewReader(body))
+		r.Header.Set("Content-Type", contentType)
+		r.Header.Set("Accept", contentType)
+		w := httptest.NewRecorder()
+		c := newContext(w, r)
+		if err := c.Bind(result); err != nil {
+			t.Errorf("bind %s fail %v", contentType, err)
+			return w
+		}
+		c.Negotiate(http.StatusOK, result, contentType)
+		return w
+	}
+
+	w := echo("application/yaml; charset=utf-8", []byte("a: b\nb: d\nc: 123\nd: true\n"), &BindObj{})
+	result := &BindObj{}
+	if err := yaml.Unmarshal(w.Body.Bytes(), result); err != nil || !cmp.Equal(result, obj) || w.Header().Get("Content-Type") != "application/yaml; charset=utf-8" {
+		t.Errorf("yaml codec fail %v %s", result, w.Header().Get("Content-Type"))
+	}
+	// 空body是空文档
+	if w = echo("application/yaml", nil, &BindObj{}); w.Code != http.StatusOK {
+		t.Errorf("yaml empty body fail %d", w.Code)
+	}
+
+	w = echo("application/x-msgpack", msgpackBody, &BindObj{})
+	values := map[string]interface{}{}
+	if err := msgpack.Unmarshal(w.Body.Bytes(), &values); err != nil ||
+		values["aaa"] != "b" || values["ddd"] != true || w.Header().Get("Content-Type") != "application/msgpack" {
+		t.Errorf("msgpack codec fail %v %s", values, w.Header().Get("Content-Type"))
+	}
+
+	w = echo("application/x-protobuf", protoBody, &wrapperspb.StringValue{})
+	message := &wrapperspb.StringValue{}
+	if err := proto.Unmarshal(w.Body.Bytes(), message); err != nil || message.GetValue() != "kelly" || w.Header().Get("Content-Type") != "application/x-protobuf" {
+		t.Errorf("protobuf codec fail %v %s", message, w.Header().Get("Content-Type"))
+	}
+
+	// 不是 proto.Message
+	r, _ := http.NewRequest(http.MethodPost, "/", bytes.NewReader(protoBody))
+	r.Header.Set("Content-Type", "application/x-protobuf")
+	if err := newContext(httptest.NewRecorder(), r).Bind(&BindObj{}); err == nil ||
+		!strings.Contains(err.Error(), binding.ErrNotProtoMessage.Error()) {
+		t.Errorf("bind protobuf to non-proto object %v", err)
+	}
+}
+
+type csvBinding struct{}
+
+func (csvBinding) Name() string {
+	return "csv"
+}
+
+func (csvBinding) Bind(r *http.Request, obj interface{}) error {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return err
+	}
+	fields := strings.Split(strings.TrimSpace(string(body)), ",")
+	if len(fields) != 2 {
+		return fmt.Errorf("invalid csv %s", body)
+	}
+	bindObj := obj.(*BindObj)
+	bindObj.A, bindObj.B = fields[0], fields[1]
+	return nil
+}
+
+func TestBindingRegistry(t *testing.T) {
+	k := New(&Config{
+		DisableBanner: true,
+		Binder:        binding.NewBinder().Register("text/csv", csvBinding{}),
+	})
+	k.POST("/",
+		BindMiddleware(func() interface{} { return &BindObj{} }, nil, nil),
+		func(c *Context) {
+			c.WriteJSON(http.StatusOK, c.GetBindParameter())
+		},
+	)
+
+	post := func(contentType, body string) *http.Response {
+		r, _ := http.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		r.Header.Set("Content-Type", contentType)
+		return k.RunTest(r)
+	}
+
+	resp := post("text/csv; charset=utf-8", "b,d")
+	if resp.StatusCode != http.StatusOK || readBody(resp) != `{"aaa":"b","bbb":"d","ccc":0,"ddd":false}` {
+		t.Errorf("bind csv fail %d", resp.StatusCode)
+	}
+
+	resp = post("application/vnd.api+json", `{"aaa":"b"}`)
+	if resp.StatusCode != http.StatusUnsupportedMediaType {
+		t.Errorf("unsupported media type %d", resp.StatusCode)
+	}
+
+	// 其他Kelly实例不受影响
+	r, _ := http.NewRequest(http.MethodPost, "/", strings.NewReader("b,d"))
+	r.Header.Set("Content-Type", "text/csv")
+	err := newContext(httptest.NewRecorder(), r).Bind(&BindObj{})
+	if !errors.Is(err, ErrUnsupportedMediaType) || !errors.Is(err, ErrBindFail) {
+		t.Errorf("default binder should not support csv %v", err)
+	}
+}
+
+func TestHeaderCookieQueryBinding(t *testing.T) {
+	type headerObj struct {
+		RequestID string `header:"x-request-id"`
+		Retry     int    `header:"X-Retry"`
+		Tags      []string
+	}
+	type cookieObj struct {
+		Session string `cookie:"session"`
+		Debug   *bool  `cookie:"debug"`
+	}
+
+	k := New(&Config{DisableBanner: true})
+	k.POST("/",
+		BindHeaderMiddleware(func() interface{} { return &headerObj{} }, nil, nil),
+		BindCookieMiddleware(func() interface{} { return &cookieObj{} }, nil, nil),
+		BindQueryMiddleware(func() interface{} { return &BindObj{} }, nil, nil),
+		func(c *Context) {
+			debug := true
+			if h := c.GetBindHeaderParameter().(*headerObj); !cmp.Equal(h, &headerObj{
+				RequestID: "abc", Retry: 3, Tags: []string{"a", "b"},
+			}) {
+				t.Errorf("bind header fail %v", h)
+			}
+			if cookie := c.GetBindCookieParameter().(*cookieObj); !cmp.Equal(cookie, &cookieObj{
+				Session: "a b", Debug: &debug,
+			}) {
+				t.Errorf("bind cookie fail %v", cookie)
+			}
+			// body中的参数不会绑定
+			if q := c.GetBindQueryParameter().(*BindObj); !cmp.Equal(q, &BindObj{A: "b", C: 123}) {
+				t.Errorf("bind query fail %v", q)
+			}
+			c.ResponseStatusOK()
+		},
+	)
+
+	r, _ := http.NewRequest(http.MethodPost, "/?aaa=b&ccc=123", strings.NewReader("bbb=d"))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r.Header.Set("X-Request-Id", "abc")
+	r.Header.Set("X-Retry", "3")
+	r.Header.Set("Tags", "a,b")
+	r.AddCookie(&http.Cookie{Name: "session", Value: "a+b"})
+	r.AddCookie(&http.Cookie{Name: "debug", Value: "true"})
+	if resp := k.RunTest(r); resp.StatusCode != http.StatusOK {
+		t.Errorf("bind fail %d %s", resp.StatusCode, readBody(resp))
+	}
+
+	r, _ = http.NewRequest(http.MethodPost, "/", nil)
+	r.Header.Set("X-Retry", "three")
+	if resp := k.RunTest(r); resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("bind invalid header %d", resp.StatusCode)
+	}
+}
+
+type requestBindObj struct {
+	ID     int      `path:"id"`
+	Page   int      `query:"page"`
+	Tags   []string `query:"tag"`
+	Tenant string   `header:"X-Tenant"`
+	SID    string   `cookie:"sid"`
+	Body   *BindObj `body:""`
+}
+
+func TestRequestBinding(t *testing.T) {
+	var errs []error
+	k := New(&Config{DisableBanner: true})
+	k.PUT("/users/:id",
+		BindRequestMiddleware(
+			func() interface{} { return &requestBindObj{} },
+			&validatorRequestObj{},
+			func(c *Context, err error) {
+				bindErr := &BindError{}
+				if errors.As(err, &bindErr) {
+					errs = bindErr.Errors
+				}
+				handleBindErr(c, err)
+			},
+		),
+		func(c *Context) {
+			obj := c.GetBindRequestParameter().(*requestBindObj)
+			if !cmp.Equal(obj, &requestBindObj{
+				ID: 12, Page: 2, Tags: []string{"a", "b"}, Tenant: "lixin", SID: "s1",
+				Body: &BindObj{A: "b", C: 123},
+			}) {
+				t.Errorf("bind request fail %v", obj)
+			}
+			c.ResponseStatusOK()
+		},
+	)
+
+	put := func(path, body string) *http.Response {
+		r, _ := http.NewRequest(http.MethodPut, path, strings.NewReader(body))
+		r.Header.Set("Content-Type", "application/json")
+		r.Header.Set("X-Tenant", "lixin")
+		r.AddCookie(&http.Cookie{Name: "sid", Value: "s1"})
+		return k.RunTest(r)
+	}
+
+	resp := put("/users/12?page=2&tag=a,b", `{"aaa":"b","ccc":123}`)
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("bind request fail %d %s", resp.StatusCode, readBody(resp))
+	}
+
+	// 所有字段的错误和校验错误一起返回
+	resp = put("/users/abc?page=x", `{"aaa":1}`)
+	if resp.StatusCode != http.StatusBadRequest || len(errs) != 4 {
+		t.Errorf("bind request errors %d %v", resp.StatusCode, errs)
+	}
+	sources := []string{}
+	for _, err := range errs {
+		if fieldErr := (*binding.FieldError)(nil); errors.As(err, &fieldErr) {
+			sources = append(sources, fieldErr.Source)
+		}
+	}
+	if !cmp.Equal(sources, []string{"path", "query", "body"}) {
+		t.Errorf("bind request error sources %v", sources)
+	}
+
+	// path变量不区分大小写（同 BindPath），body只能绑定一个字段
+	type multiBodyObj struct {
+		ID    int      `path:"ID"`
+		Body  *BindObj `body:""`
+		Extra *BindObj `body:""`
+	}
+	called := false
+	k = New(&Config{DisableBanner: true})
+	k.POST("/items/:id", func(c *Context) {
+		called = true
+		obj := &multiBodyObj{}
+		err := c.BindAll(obj)
+		if obj.ID != 7 || obj.Body == nil || obj.Body.A != "b" || !errors.Is(err, binding.ErrMultipleBodyFields) {
+			t.Errorf("bind all path/body fail %v|%v", obj, err)
+		}
+		c.ResponseStatusOK()
+	})
+	r, _ := http.NewRequest(http.MethodPost, "/items/7", strings.NewReader(`{"aaa":"b"}`))
+	r.Header.Set("Content-Type", "application/json")
+	if k.RunTest(r); !called {
+		t.Errorf("bind all handler not called")
+	}
+}
+
+type validatorRequestObj struct{}
+
+func (v validatorRequestObj) Validate(obj interface{}, params ...string) error {
+	if obj.(*requestBindObj).ID <= 0 {
+		return fmt.Errorf("invalid id")
+	}
+	return nil
+}
+
+type levelText int
+
+func (l *levelText) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "low":
+		*l = 1
+	case "high":
+		*l = 2
+	default:
+		return fmt.Errorf("invalid level %s", text)
+	}
+	return nil
+}
+
+type Pagination struct {
+	Page int `json:"page"`
+	Size int `json:"size"`
+}
+
+type itemObj struct {
+	Name  string `json:"name"`
+	Count *int   `json:"count"`
+}
+
+type formTypesObj struct {
+	*Pagination
+	Since   time.Time         `json:"since" time_format:"2006-01-02" time_utc:"true"`
+	Until   time.Time         `json:"until" time_format:"unix"`
+	Timeout time.Duration     `json:"timeout"`
+	Level   levelText         `json:"level"`
+	Levels  []*levelText      `json:"levels"`
+	Filter  map[string]string `json:"filter"`
+	Counts  map[string][]int  `json:"counts"`
+	Items   []itemObj         `json:"items"`
+	Owner   *itemObj          `json:"owner"`
+	Empty   *itemObj          `json:"empty"`
+	Ignored string            `json:"-"`
+}
+
+func TestFormMappingTypes(t *testing.T) {
+	query := "page=2&size=10" +
+		"&since=2023-01-02&until=1672531200&timeout=1m30s" +
+		"&level=high&levels[]=low&levels[]=high" +
+		"&filter[status]=open&filter[owner]=me&counts[a]=1,2" +
+		"&items[1][name]=b&items[0].name=a&items[0].count=3" +
+		"&owner.name=lixin&Ignored=x"
+	r, _ := http.NewRequest(http.MethodGet, "/?"+query, nil)
+	obj := &formTypesObj{}
+	if err := newContext(httptest.NewRecorder(), r).BindQuery(obj); err != nil {
+		t.Fatalf("bind query fail %v", err)
+	}
+
+	low, high, count := levelText(1), levelText(2), 3
+	expected := &formTypesObj{
+		Pagination: &Pagination{Page: 2, Size: 10},
+		Since:      time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC),
+		Until:      time.Unix(1672531200, 0),
+		Timeout:    90 * time.Second,
+		Level:      high,
+		Levels:     []*levelText{&low, &high},
+		Filter:     map[string]string{"status": "open", "owner": "me"},
+		Counts:     map[string][]int{"a": {1, 2}},
+		Items:      []itemObj{{Name: "a", Count: &count}, {Name: "b"}},
+		Owner:      &itemObj{Name: "lixin"},
+	}
+	if !cmp.Equal(obj, expected, cmp.AllowUnexported(formTypesObj{})) {
+		t.Errorf("form mapping types %s", cmp.Diff(obj, expected, cmp.AllowUnexported(formTypesObj{})))
+	}
+
+	for _, invalid := range []string{"timeout=1x", "level=middle", "since=2023/01/02", "items[100000].name=a"} {
+		r, _ = http.NewRequest(http.MethodGet, "/?"+invalid, nil)
+		if err := newContext(httptest.NewRecorder(), r).BindQuery(&formTypesObj{}); err == nil {
+			t.Errorf("bind invalid query %s", invalid)
+		}
+	}
+}
+
+type defaultBindObj struct {
+	binding.FieldSet
+	Page   int      `json:"page" default:"1"`
+	Size   *int     `json:"size"`
+	Sort   []string `json:"sort" default:"id,name"`
+	Active bool     `json:"active"`
+	Query  string   `json:"q"`
+}
+
+func TestFormDefaultAndStrict(t *testing.T) {
+	bind := func(binder *binding.Binder, query string) (*defaultBindObj, error) {
+		r, _ := http.NewRequest(http.MethodGet, "/?"+query, nil)
+		obj := &defaultBindObj{}
+		return obj, binder.BindQuery(r, obj)
+	}
+
+	obj, err := bind(binding.NewBinder(), "active=&q=")
+	if err != nil || obj.Page != 1 || obj.Size != nil || !cmp.Equal(obj.Sort, []string{"id", "name"}) {
+		t.Errorf("bind default fail %v %v", obj, err)
+	}
+	if !cmp.Equal(obj.Fields(), []string{"active", "q"}) || obj.Has("page") {
+		t.Errorf("field set %v", obj.Fields())
+	}
+
+	obj, err = bind(binding.NewBinder(), "page=0&size=0")
+	if err != nil || obj.Page != 0 || obj.Size == nil || *obj.Size != 0 || !obj.Has("size") {
+		t.Errorf("bind zero value fail %v %v", obj, err)
+	}
+
+	// 不再忽略bool的转换错误
+	if _, err = bind(binding.NewBinder(), "active=yes"); err == nil {
+		t.Errorf("bind invalid bool should fail")
+	}
+
+	// 严格模式返回所有字段的错误
+	obj, err = bind(&binding.Binder{Strict: true}, "page=&size=x&active=yes&q=")
+	errs, ok := err.(binding.FieldErrors)
+	if !ok || len(errs) != 3 {
+		t.Fatalf("strict mode errors %v", err)
+	}
+	// 转换失败的指针字段保持nil
+	if obj.Size != nil {
+		t.Errorf("invalid pointer field should be nil %v", *obj.Size)
+	}
+	fieldErr := &binding.FieldError{}
+	if !errors.As(errs[0], &fieldErr) || fieldErr.Key != "page" || !errors.Is(fieldErr, binding.ErrEmptyValue) {
+		t.Errorf("strict mode field error %v", errs[0])
+	}
+
+	k := New(&Config{DisableBanner: true, Binder: &binding.Binder{Strict: true}})
+	k.GET("/", func(c *Context) {
+		err := c.BindQuery(&defaultBindObj{})
+		bindErr := &BindError{}
+		if !errors.As(err, &bindErr) || len(bindErr.Errors) != 2 || !errors.Is(err, ErrBindFail) {
+			t.Errorf("strict mode bind error %v", err)
+		}
+		c.ResponseStatusOK()
+	})
+	r, _ := http.NewRequest(http.MethodGet, "/?page=a&size=", nil)
+	k.RunTest(r)
+}
+
+func TestBindingLimits(t *testing.T) {
+	binder := &binding.Binder{
+		MaxBodySize:        64,
+		MaxMultipartMemory: 16,
+		JSON:               binding.JSONOptions{DisallowUnknownFields: true, UseNumber: true, DisallowTrailingData: true},
+	}
+	k := New(&Config{DisableBanner: true, Binder: binder})
+	handler := func(c *Context) {
+		c.WriteJSON(http.StatusOK, c.GetBindParameter())
+	}
+	k.POST("/",
+		BindMiddleware(func() interface{} { return &BindObj{} }, nil, nil),
+		handler,
+	)
+	k.POST("/map",
+		BindMiddleware(func() interface{} { return &map[string]interface{}{} }, nil, nil),
+		func(c *Context) {
+			value := (*c.GetBindParameter().(*map[string]interface{}))["id"]
+			if _, ok := value.(json.Number); !ok {
+				t.Errorf("use number fail %T", value)
+			}
+			c.ResponseStatusOK()
+		},
+	)
+	upload := binder.Clone()
+	upload.MaxBodySize = 1 << 20
+	k.POST("/upload",
+		BinderMiddleware(upload),
+		BindMiddleware(func() interface{} { return &BindObj{} }, nil, nil),
+		handler,
+	)
+	k.Group("/large").POST("/",
+		BinderMiddleware(&binding.Binder{MaxBodySize: 1 << 20}),
+		BindMiddleware(func() interface{} { return &BindObj{} }, nil, nil),
+		handler,
+	)
+
+	post := func(path, contentType, body string) *http.Response {
+		r, _ := http.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		r.Header.Set("Content-Type", contentType)
+		return k.RunTest(r)
+	}
+
+	large := `{"aaa":"` + strings.Repeat("a", 100) + `"}`
+	cases := []struct {
+		path string
+		body string
+		code int
+	}{
+		{"/", `{"aaa":"b"}`, http.StatusOK},
+		{"/", `{"aaa":"b"}` + "\n", http.StatusOK},
+		{"/", `{"aaa":"b"}{"bbb":"d"}`, http.StatusBadRequest},
+		{"/", `{"aaa":"b","eee":1}`, http.StatusBadRequest},
+		{"/", large, http.StatusRequestEntityTooLarge},
+		{"/map", `{"id":12345678901234567890}`, http.StatusOK},
+		{"/large/", large, http.StatusOK},
+		// 缺省忽略json之后的数据
+		{"/large/", `{"aaa":"b"}{"bbb":"d"}`, http.StatusOK},
+	}
+	for _, tc := range cases {
+		resp := post(tc.path, "application/json", tc.body)
+		if resp.StatusCode != tc.code {
+			t.Errorf("%s %s => %d, expected %d", tc.path, tc.body, resp.StatusCode, tc.code)
+		}
+	}
+
+	// multipart表单超过 MaxMultipartMemory 的部分写入临时文件
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	writer.WriteField("aaa", "b")
+	writer.WriteField("bbb", strings.Repeat("d", 20))
+	writer.Close()
+	resp := post("/upload", writer.FormDataContentType(), body.String())
+	if resp.StatusCode != http.StatusOK || !strings.Contains(readBody(resp), `"aaa":"b"`) {
+		t.Errorf("bind multipart form fail %d", resp.StatusCode)
+	}
+}
+
+func TestBodyCache(t *testing.T) {
+	k := New(&Config{DisableBanner: true, BodyCacheLimit: 32})
+	bindTwice := func(c *Context) {
+		first, second := &BindObj{}, map[string]interface{}{}
+		if err := c.BindJSON(first); err != nil {
+			c.WriteString(http.StatusBadRequest, err.Error())
+			return
+		}
+		if err := c.Bind(&second); err != nil {
+			c.WriteString(http.StatusBadRequest, err.Error())
+			return
+		}
+		body, _ := c.Body()
+		c.WriteString(http.StatusOK, "%s|%v|%s", first.A, second["aaa"], body)
+	}
+	k.POST("/cached", CacheBodyMiddleware(nil), func(c *Context) {
+		// 模拟签名校验等读取body的中间件
+		body, err := c.Body()
+		if err != nil || string(body) != `{"aaa":"b"}` {
+			t.Errorf("read cached body fail %s %v", body, err)
+		}
+		c.InvokeNext()
+	}, bindTwice)
+	k.POST("/", bindTwice)
+
+	post := func(path, body string) *http.Response {
+		r, _ := http.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		r.Header.Set("Content-Type", "application/json")
+		return k.RunTest(r)
+	}
+
+	resp := post("/cached", `{"aaa":"b"}`)
+	if result := readBody(resp); resp.StatusCode != http.StatusOK || result != `b|b|{"aaa":"b"}` {
+		t.Errorf("bind cached body fail %d %s", resp.StatusCode, result)
+	}
+
+	// 没有缓存时body只能读取一次
+	if resp = post("/", `{"aaa":"b"}`); resp.StatusCode != http.StatusBadRequest || !strings.Contains(readBody(resp), "EOF") {
+		t.Errorf("bind body twice without cache %d", resp.StatusCode)
+	}
+
+	if resp = post("/cached", `{"aaa":"`+strings.Repeat("b", 32)+`"}`); resp.StatusCode != http.StatusRequestEntityTooLarge {
+		t.Errorf("body cache limit %d", resp.StatusCode)
+	}
+}
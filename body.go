@@ -0,0 +1,72 @@
+package kelly
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// DefaultBodyCacheLimit Context.Body 缓存body的缺省最大字节数
+const DefaultBodyCacheLimit = 10 << 20 // 10 MB
+
+// Body 读取并缓存请求的body，之后的绑定都从缓存读取，可以重复绑定
+// 缓存后 Request().Body 也替换为缓存的数据（每次绑定前重置）
+// body超过 Config.BodyCacheLimit 时返回 ErrBodyTooLarge
+func (c *Context) Body() ([]byte, error) {
+	if c.bodyCached {
+		return c.body, c.bodyErr
+	}
+	c.bodyCached = true
+
+	if c.r.Body == nil || c.r.Body == http.NoBody {
+		c.body = []byte{}
+		return c.body, nil
+	}
+
+	limit := c.bodyCacheLimit()
+	body, err := io.ReadAll(io.LimitReader(c.r.Body, limit+1))
+	c.r.Body.Close()
+	if err == nil && int64(len(body)) > limit {
+		err = fmt.Errorf("body cache limit %d bytes, : %w", limit, ErrBodyTooLarge)
+	}
+	if err != nil {
+		// 已经读取的数据不完整，之后的绑定也会失败
+		c.body, c.bodyErr = nil, err
+		c.r.Body = http.NoBody
+		return nil, err
+	}
+
+	c.body = body
+	c.rewindBody()
+	return c.body, nil
+}
+
+// rewindBody 若已经缓存了body，重新设置 Request().Body，绑定前调用
+func (c *Context) rewindBody() {
+	if c.bodyCached && c.bodyErr == nil {
+		c.r.Body = io.NopCloser(bytes.NewReader(c.body))
+	}
+}
+
+func (c *Context) bodyCacheLimit() int64 {
+	if k, ok := c.k.(*kellyImp); ok && k.config.BodyCacheLimit > 0 {
+		return k.config.BodyCacheLimit
+	}
+	return DefaultBodyCacheLimit
+}
+
+// CacheBodyMiddleware 缓存body（见 Context.Body），之后的handler可以重复绑定、读取body
+// 读取失败时交给errHandler处理（缺省body太大返回413，其他错误返回400）
+func CacheBodyMiddleware(errHandler BindErrorHandle) HandlerFunc {
+	if errHandler == nil {
+		errHandler = handleBindErr
+	}
+	return func(c *Context) {
+		if _, err := c.Body(); err != nil {
+			errHandler(c, err)
+			return
+		}
+		c.InvokeNext()
+	}
+}
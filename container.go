@@ -0,0 +1,101 @@
+package kelly
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Key 类型化的key，通过 Key.Get/Key.MustGet 获取数据时无需再指定值的类型
+// 使用指针作为key，不同的Key即使name相同也不会冲突
+type Key[T any] struct {
+	name string
+}
+
+// NewKey 创建类型化的key，name仅用于错误信息
+func NewKey[T any](name string) *Key[T] {
+	return &Key[T]{name: name}
+}
+
+func (k *Key[T]) String() string {
+	return k.name
+}
+
+// Get 同 kelly.Get，值的类型由key决定
+// eg. db, ok := dbKey.Get(c)
+func (k *Key[T]) Get(c *Context) (T, bool) {
+	return Get[T](c, k)
+}
+
+// MustGet 同 kelly.MustGet，值的类型由key决定
+func (k *Key[T]) MustGet(c *Context) T {
+	return MustGet[T](c, k)
+}
+
+// container 全局（Kelly实例级别）的服务注册表，eg. 数据库连接池
+type container struct {
+	mu       sync.RWMutex
+	services map[interface{}]interface{}
+}
+
+func (c *container) provide(key, value interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.services == nil {
+		c.services = make(map[interface{}]interface{})
+	}
+	c.services[key] = value
+}
+
+func (c *container) resolve(key interface{}) (interface{}, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	value, ok := c.services[key]
+	return value, ok
+}
+
+// Provide 注册全局服务，所有请求的Context都可以通过 Resolve/Get 获取
+func (k *kellyImp) Provide(key, value interface{}) {
+	k.container.provide(key, value)
+}
+
+// Resolve 获取全局服务
+func (k *kellyImp) Resolve(key interface{}) (interface{}, bool) {
+	return k.container.resolve(key)
+}
+
+// Resolve 获取数据，优先使用请求级别的数据（Set），其次是Kelly实例注册的全局服务（Provide）
+func (c *Context) Resolve(key interface{}) (interface{}, bool) {
+	if value, ok := c.data.lookup(key); ok {
+		return value, true
+	}
+	if c.k != nil {
+		return c.k.Resolve(key)
+	}
+	return nil, false
+}
+
+// Get 类型安全地获取数据，不存在或者类型不匹配时返回false，查找顺序同 Context.Resolve
+// eg. db, ok := kelly.Get[*sql.DB](c, "db")
+func Get[T any](c *Context, key interface{}) (T, bool) {
+	var zero T
+	value, ok := c.Resolve(key)
+	if !ok {
+		return zero, false
+	}
+	result, ok := value.(T)
+	return result, ok
+}
+
+// MustGet 同Get，不存在或者类型不匹配时报错
+func MustGet[T any](c *Context, key interface{}) T {
+	value, ok := c.Resolve(key)
+	if !ok {
+		panic(fmt.Errorf("context key(%v) not exist: %w", key, ErrNoContextData))
+	}
+	result, ok := value.(T)
+	if !ok {
+		var zero T
+		panic(fmt.Errorf("context key(%v) is %T, not %T: %w", key, value, zero, ErrContextDataType))
+	}
+	return result
+}
@@ -0,0 +1,61 @@
+package kelly
+
+import (
+	"net/http"
+	"testing"
+)
+
+type testDB struct {
+	name string
+}
+
+func TestContainer(t *testing.T) {
+	dbKey := NewKey[*testDB]("db")
+	k := New(&Config{DisableBanner: true})
+	k.Provide(dbKey, &testDB{name: "global"})
+	k.Provide("count", 1)
+
+	var results []string
+	k.GET("/", func(c *Context) {
+		db, ok := dbKey.Get(c)
+		if !ok || db != MustGet[*testDB](c, dbKey) {
+			t.Errorf("typed key get fail")
+		}
+		results = append(results, db.name)
+
+		// 类型不匹配
+		if _, ok = Get[string](c, "count"); ok {
+			t.Errorf("get with wrong type fail")
+		}
+		if count := MustGet[int](c, "count"); count != 1 {
+			t.Errorf("must get fail %d", count)
+		}
+
+		// 请求级别覆盖
+		c.Set(dbKey, &testDB{name: "request"})
+		results = append(results, dbKey.MustGet(c).name)
+
+		if _, ok := c.Resolve("none"); ok {
+			t.Errorf("resolve none exist key fail")
+		}
+		c.WriteString(http.StatusOK, "ok")
+	})
+
+	for i := 0; i < 2; i++ {
+		r, _ := http.NewRequest(http.MethodGet, "/", nil)
+		k.RunTest(r)
+	}
+	if len(results) != 4 || results[0] != "global" || results[1] != "request" || results[2] != "global" {
+		t.Errorf("container fail %v", results)
+	}
+
+	func() {
+		defer func() {
+			if r := recover(); r == nil {
+				t.Errorf("must get with wrong type not panic")
+			}
+		}()
+		r, _ := http.NewRequest(http.MethodGet, "/", nil)
+		MustGet[string](newContext(nil, r), dbKey)
+	}()
+}
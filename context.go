@@ -1,36 +1,121 @@
 package kelly
 
 import (
+	"bufio"
 	"context"
-	"fmt"
+	"crypto/rand"
+	"encoding/hex"
+	"io"
+	"net"
 	"net/http"
+	"sync"
 
+	"github.com/julienschmidt/httprouter"
 	"github.com/lixinio/kelly/binding"
 )
 
 const (
-	contextNextHandle         = "__next_handler" // 下一个handler
-	contextDataKeyPathVarible = "__path_varible" // path变量
+	// HeaderRequestID 请求ID，若请求没有携带，则自动生成
+	HeaderRequestID = "X-Request-Id"
 )
 
-var (
-	gBinder binderAdapter = nil // 全局的binder
-)
-
-func init() {
-	gBinder = binding.NewBinder()
-}
+// defaultBinder Context不是由Kelly创建时使用
+var defaultBinder binderAdapter = binding.NewBinder()
 
 // Context kelly在调用链传递的对象， 包装request/response
+// Context 在请求结束后会被回收复用，不能在handler返回后（例如另起的goroutine中）继续使用
 type Context struct {
-	http.Hijacker
-	http.Flusher
-	http.ResponseWriter               // 备份http.ResponseWriter
-	contextData                       // 支持绑定自定义数据
-	r                   *http.Request // 备份http.Request
-	response                          // 处理各种输出
-	request                           // 读取各种请求参数
-	binder                            // 绑定参数到对象
+	http.ResponseWriter                  // 备份http.ResponseWriter
+	contextData                          // 支持绑定自定义数据
+	r                   *http.Request    // 备份http.Request
+	response                             // 处理各种输出
+	request                              // 读取各种请求参数
+	binder                               // 绑定参数到对象
+	k                   Kelly            // 所属Kelly实例
+	route               string           // 路由模板
+	requestID           string           // 请求ID
+	logger              StructuredLogger // 请求级别的日志
+	writer              responseWriter   // 记录响应状态的http.ResponseWriter
+	params              httprouter.Params
+	handlers            []HandlerFunc // 调用链
+	index               int           // 当前执行的handler
+	aborted             bool          // 是否已经中止调用链
+	err                 error         // AbortWithError 记录的错误
+	finishHooks         []HandlerFunc // 请求结束后的回调
+	beforeWriteHooks    []HandlerFunc // 发送响应头之前的回调
+	body                []byte        // 缓存的body，见 Body
+	bodyErr             error         // 读取body的错误
+	bodyCached          bool          // 是否已经缓存body
+
+	// 以下对象随Context一起分配，避免每个请求单独分配
+	data contextMapData
+	resp responseImp
+	req  requestImp
+	bind binderImp
+}
+
+// Status 获得响应码，若还没有响应，返回200
+func (c *Context) Status() int {
+	return c.writer.Status()
+}
+
+// Size 获得已经输出的body字节数
+func (c *Context) Size() int64 {
+	return c.writer.Size()
+}
+
+// Written 是否已经发送了响应头
+func (c *Context) Written() bool {
+	return c.writer.Written()
+}
+
+// Kelly 获得所属的Kelly实例，若Context不是由Kelly创建，则返回nil
+func (c *Context) Kelly() Kelly {
+	return c.k
+}
+
+// Route 获得匹配的路由模板 eg. /users/:id
+func (c *Context) Route() string {
+	return c.route
+}
+
+// RequestID 获得请求ID，优先使用请求头 X-Request-Id
+func (c *Context) RequestID() string {
+	if c.requestID == "" {
+		c.requestID = c.r.Header.Get(HeaderRequestID)
+		if c.requestID == "" {
+			c.requestID = newRequestID()
+		}
+	}
+	return c.requestID
+}
+
+// Logger 获得请求级别的日志，预置了 request_id/method/route 字段
+func (c *Context) Logger() StructuredLogger {
+	if c.logger == nil {
+		c.logger = c.baseLogger().With(
+			"request_id", c.RequestID(),
+			"method", c.r.Method,
+			"route", c.route,
+		)
+	}
+	return c.logger
+}
+
+// baseLogger Kelly实例的日志，没有预置请求字段
+func (c *Context) baseLogger() StructuredLogger {
+	if c.k != nil {
+		return c.k.Logger()
+	}
+	return defaultLogger
+}
+
+func newRequestID() string {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(buf)
 }
 
 // Request 获得http.Request对象
@@ -44,6 +129,7 @@ func (c *Context) Request() *http.Request {
 // 需要重新设置request
 func (c *Context) SetRequest(r *http.Request) *Context {
 	c.r = r
+	c.req.Request = r
 	return c
 }
 
@@ -52,32 +138,245 @@ func (c *Context) SetResponseWriter(w http.ResponseWriter) *Context {
 	return c
 }
 
+// WriteHeader 发送响应头，调用链中止后拒绝
+// 只拒绝handler通过Context的输出，中间件包装的writer（eg. gzip）在调用链返回时的输出不受影响
+func (c *Context) WriteHeader(code int) {
+	if c.rejected("write header") {
+		return
+	}
+	c.ResponseWriter.WriteHeader(code)
+}
+
+// Write 输出body，调用链中止后拒绝，返回 ErrWriteAfterAbort
+func (c *Context) Write(data []byte) (int, error) {
+	if c.rejected("write") {
+		return 0, ErrWriteAfterAbort
+	}
+	return c.ResponseWriter.Write(data)
+}
+
+// rejected 调用链中止后拒绝输出，并记录日志
+func (c *Context) rejected(op string) bool {
+	if !c.aborted {
+		return false
+	}
+	c.Logger().Warn("response "+op+" after abort is rejected", "status", c.Status())
+	return true
+}
+
+// Flush 将缓冲的数据发送到客户端，若底层不支持http.Flusher，则忽略
+func (c *Context) Flush() {
+	if flusher, ok := c.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Hijack 接管底层连接，之后不能再通过Context输出响应
+func (c *Context) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if hijacker, ok := c.ResponseWriter.(http.Hijacker); ok {
+		return hijacker.Hijack()
+	}
+	return nil, nil, ErrNotHijacker
+}
+
+// Stream 分块输出响应，每次调用step后Flush，step返回false或者客户端断开连接时结束
+// 返回值表示客户端是否已经断开
+func (c *Context) Stream(step func(w io.Writer) bool) bool {
+	done := c.Context().Done()
+	for {
+		select {
+		case <-done:
+			return true
+		default:
+			keepOpen := step(c)
+			c.Flush()
+			if !keepOpen {
+				return false
+			}
+		}
+	}
+}
+
 // Context 获得 context.Context
 func (c *Context) Context() context.Context {
 	return c.r.Context()
 }
 
-// InvokeNext 触发调用链的下一个handler
-func (c *Context) InvokeNext() {
-	n := c.Get(contextNextHandle)
-	if n != nil {
-		rn := n.(*handlerChainEntry)
-		if rn == nil {
-			fmt.Println("fuck")
+// OnFinish 注册请求结束后的回调，按注册的逆序执行，调用链panic时也会执行
+// 可以用于审计日志、统计、释放资源等
+func (c *Context) OnFinish(hook HandlerFunc) *Context {
+	c.finishHooks = append(c.finishHooks, hook)
+	return c
+}
+
+// OnBeforeWrite 注册发送响应头之前的回调，按注册的顺序执行，可以在回调中继续添加响应头 eg. Server-Timing
+// 回调中也可以注册回调，在当前这一批回调之后执行；若注册时响应头已经发送，回调不会执行
+func (c *Context) OnBeforeWrite(hook HandlerFunc) *Context {
+	c.beforeWriteHooks = append(c.beforeWriteHooks, hook)
+	return c
+}
+
+func (c *Context) runFinishHooks() {
+	for i := len(c.finishHooks) - 1; i >= 0; i-- {
+		c.finishHooks[i](c)
+	}
+}
+
+// runBeforeWriteHooks 回调中注册的回调同样在发送响应头之前执行
+func (c *Context) runBeforeWriteHooks() {
+	registered := c.beforeWriteHooks
+	for hooks := registered; len(hooks) > 0; hooks = c.beforeWriteHooks {
+		// 回调中可能再次输出，避免重复执行
+		c.beforeWriteHooks = nil
+		for _, hook := range hooks {
+			hook(c)
 		}
-		rn.ServeHTTP(c)
 	}
+	c.beforeWriteHooks = clearHooks(registered)
+}
+
+// InvokeNext 触发调用链的下一个handler，调用链中止后不再执行
+func (c *Context) InvokeNext() {
+	if c.aborted {
+		return
+	}
+	c.index++
+	if c.index < len(c.handlers) {
+		c.handlers[c.index](c)
+	}
+}
+
+// abort 中止调用链，之后通过Context的输出会被拒绝
+func (c *Context) abort() {
+	c.aborted = true
+}
+
+// IsAborted 调用链是否已经中止
+func (c *Context) IsAborted() bool {
+	return c.aborted
+}
+
+// AbortWithStatus 只返回响应码（没有body），并中止调用链
+func (c *Context) AbortWithStatus(code int) {
+	if !c.aborted {
+		c.WriteHeader(code)
+		c.abort()
+	}
+}
+
+// AbortWithError 返回json格式的错误（同 Abort），记录错误，并中止调用链
+func (c *Context) AbortWithError(code int, err error) {
+	if c.aborted {
+		return
+	}
+	c.err = err
+	msg := ""
+	if err != nil {
+		msg = err.Error()
+	}
+	c.Abort(code, msg)
+}
+
+// handleError 交给 Config.HandleError 处理错误，Context不是由Kelly创建时使用缺省处理
+func (c *Context) handleError(err error) {
+	if k, ok := c.k.(*kellyImp); ok && k.config.HandleError != nil {
+		k.config.HandleError(c, err)
+		return
+	}
+	defaultHandleError(c, err)
+}
+
+// getBinder 优先使用 BinderMiddleware 设置的Binder，其次是 Config.Binder，Context不是由Kelly创建时使用缺省的Binder
+func (c *Context) getBinder() binderAdapter {
+	if binder, ok := binderKey.Get(c); ok {
+		return binder
+	}
+	return defaultBinder
+}
+
+// Err 获得 AbortWithError 记录的错误
+func (c *Context) Err() error {
+	return c.err
+}
+
+// checkChain 检查调用链是否异常结束：某个handler既没有输出，也没有调用 InvokeNext
+func (c *Context) checkChain() {
+	if c.aborted || c.Written() || c.index < 0 || c.index >= len(c.handlers)-1 {
+		return
+	}
+	c.Logger().Warn("handler neither wrote a response nor called InvokeNext",
+		"handler", nameOfFunction(c.handlers[c.index]),
+	)
+}
+
+var contextPool = sync.Pool{
+	New: func() interface{} {
+		return allocContext()
+	},
+}
+
+// allocContext 分配Context，并关联内嵌的各个实现
+func allocContext() *Context {
+	c := &Context{}
+	c.writer.c = c
+	c.contextData = &c.data
+	c.resp.ResponseWriter = c
+	c.resp.c = c
+	c.response = &c.resp
+	c.req.Context = c
+	c.request = &c.req
+	c.bind.c = c
+	c.binder = &c.bind
+	return c
+}
+
+// reset 复用前重置请求相关的状态
+func (c *Context) reset(w http.ResponseWriter, r *http.Request) {
+	c.writer.reset(w)
+	c.ResponseWriter = &c.writer
+	c.r = r
+	c.req.Request = r
+	c.data.reset()
+	c.k = nil
+	c.route = ""
+	c.requestID = ""
+	c.logger = nil
+	c.params = nil
+	c.handlers = nil
+	c.index = -1
+	c.aborted = false
+	c.err = nil
+	c.body = nil
+	c.bodyErr = nil
+	c.bodyCached = false
+	c.finishHooks = clearHooks(c.finishHooks)
+	c.beforeWriteHooks = clearHooks(c.beforeWriteHooks)
+}
+
+// clearHooks 释放回调的引用，保留已经分配的空间
+func clearHooks(hooks []HandlerFunc) []HandlerFunc {
+	for i := range hooks {
+		hooks[i] = nil
+	}
+	return hooks[:0]
+}
+
+func acquireContext(w http.ResponseWriter, r *http.Request) *Context {
+	c := contextPool.Get().(*Context)
+	c.reset(w, r)
+	return c
+}
+
+func releaseContext(c *Context) {
+	c.runFinishHooks()
+	// 释放引用，避免pool持有请求数据
+	c.reset(nil, nil)
+	contextPool.Put(c)
 }
 
 func newContext(w http.ResponseWriter, r *http.Request) *Context {
-	c := &Context{
-		ResponseWriter: w,
-		r:              r,
-		contextData:    newContextMapData(),
-	}
-	c.response = newResponse(c)
-	c.request = newRequest(c, r)
-	c.binder = newBinder(c, gBinder)
+	c := allocContext()
+	c.reset(w, r)
 	return c
 }
 
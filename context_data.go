@@ -20,10 +20,27 @@ type contextMapData struct {
 }
 
 func (c *contextMapData) Set(key, value interface{}) contextData {
+	if c.data == nil {
+		// 大部分请求不需要存储数据，延迟创建
+		c.data = make(contextDataMap)
+	}
 	c.data[key] = value
 	return c
 }
 
+// reset 清空数据，保留已经分配的map
+func (c *contextMapData) reset() {
+	for key := range c.data {
+		delete(c.data, key)
+	}
+}
+
+// lookup 读取数据，同时返回是否存在
+func (c contextMapData) lookup(key interface{}) (interface{}, bool) {
+	data, ok := c.data[key]
+	return data, ok
+}
+
 func (c contextMapData) Get(key interface{}) interface{} {
 	if data, ok := c.data[key]; ok {
 		return data
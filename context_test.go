@@ -1,8 +1,15 @@
 package kelly
 
 import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 )
 
@@ -109,3 +116,216 @@ func TestContext(t *testing.T) {
 		}
 	}
 }
+
+func TestContextFlushHijack(t *testing.T) {
+	// httptest.ResponseRecorder 不支持Hijack
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodGet, "/", nil)
+	c := newContext(w, r)
+	c.Flush()
+	if !w.Flushed {
+		t.Errorf("flush fail")
+	}
+	if _, _, err := c.Hijack(); err != ErrNotHijacker {
+		t.Errorf("hijack fail %v", err)
+	}
+
+	k := New(nil)
+	k.GET("/", func(c *Context) {
+		conn, rw, err := c.Hijack()
+		if err != nil {
+			t.Errorf("hijack fail %v", err)
+			return
+		}
+		defer conn.Close()
+		rw.WriteString("HTTP/1.1 200 OK\r\nContent-Length: 6\r\nConnection: close\r\n\r\nhijack")
+		rw.Flush()
+	})
+	srv := httptest.NewServer(k)
+	defer srv.Close()
+	resp, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("get fail %v", err)
+	}
+	if body := readBody(resp); body != "hijack" {
+		t.Errorf("hijack body fail %s", body)
+	}
+}
+
+func TestContextStream(t *testing.T) {
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodGet, "/", nil)
+	c := newContext(w, r)
+	count := 0
+	clientGone := c.Stream(func(w io.Writer) bool {
+		count++
+		fmt.Fprintf(w, "%d", count)
+		return count < 3
+	})
+	if clientGone || w.Body.String() != "123" || !w.Flushed {
+		t.Errorf("stream fail %v|%s", clientGone, w.Body.String())
+	}
+
+	// 客户端断开
+	ctx, cancel := context.WithCancel(context.Background())
+	w = httptest.NewRecorder()
+	c = newContext(w, r.WithContext(ctx))
+	count = 0
+	clientGone = c.Stream(func(w io.Writer) bool {
+		count++
+		if count == 2 {
+			cancel()
+		}
+		return true
+	})
+	if !clientGone || count != 2 {
+		t.Errorf("stream cancel fail %v|%d", clientGone, count)
+	}
+}
+
+func TestContextReuse(t *testing.T) {
+	r, _ := http.NewRequest(http.MethodGet, "/", nil)
+	c := acquireContext(httptest.NewRecorder(), r)
+	c.Set("key", "value")
+	c.WriteHeader(http.StatusCreated)
+	releaseContext(c)
+
+	// 复用的Context不能残留上一个请求的状态
+	for i := 0; i < 10; i++ {
+		c = acquireContext(httptest.NewRecorder(), r)
+		if c.Get("key") != nil || c.Written() || c.Status() != http.StatusOK {
+			t.Errorf("context reset fail")
+		}
+		releaseContext(c)
+	}
+}
+
+func TestContextHooks(t *testing.T) {
+	var results []string
+	finished := make(chan struct{})
+	k := New(&Config{DisableBanner: true})
+	k.GET("/panic",
+		func(c *Context) {
+			c.OnFinish(func(c *Context) {
+				results = append(results, "finish1")
+				close(finished)
+			})
+			c.OnFinish(func(c *Context) {
+				results = append(results, "finish2")
+			})
+			c.InvokeNext()
+		},
+		func(c *Context) {
+			panic("oops")
+		},
+	)
+	k.GET("/timing", func(c *Context) {
+		c.OnBeforeWrite(func(c *Context) {
+			c.SetHeader("Server-Timing", "db;dur=1")
+			// 回调中输出不能重复执行回调
+			c.WriteHeader(http.StatusAccepted)
+		})
+		c.WriteString(http.StatusOK, "ok")
+	})
+	k.GET("/nested", func(c *Context) {
+		c.OnBeforeWrite(func(c *Context) {
+			// 回调中注册的回调同样执行
+			c.OnBeforeWrite(func(c *Context) {
+				c.SetHeader("X-Nested", "1")
+			})
+		})
+		c.WriteString(http.StatusOK, "ok")
+	})
+
+	srv := httptest.NewUnstartedServer(k)
+	srv.Config.ErrorLog = log.New(io.Discard, "", 0)
+	srv.Start()
+	defer srv.Close()
+
+	// panic后连接被net/http断开
+	if _, err := http.Get(srv.URL + "/panic"); err == nil {
+		t.Errorf("panic request should fail")
+	}
+	<-finished
+	if len(results) != 2 || results[0] != "finish2" || results[1] != "finish1" {
+		t.Errorf("on finish fail %v", results)
+	}
+
+	resp, err := http.Get(srv.URL + "/timing")
+	if err != nil {
+		t.Fatalf("get fail %v", err)
+	}
+	if resp.StatusCode != http.StatusAccepted || resp.Header.Get("Server-Timing") != "db;dur=1" {
+		t.Errorf("on before write fail %d|%s", resp.StatusCode, resp.Header.Get("Server-Timing"))
+	}
+	resp, err = http.Get(srv.URL + "/nested")
+	if err != nil {
+		t.Fatalf("get fail %v", err)
+	}
+	if resp.Header.Get("X-Nested") != "1" {
+		t.Errorf("nested on before write fail")
+	}
+}
+
+func TestContextAbort(t *testing.T) {
+	out := &bytes.Buffer{}
+	k := New(&Config{DisableBanner: true, Logger: NewTextLogger(out, LevelDebug)})
+	errAuth := errors.New("auth fail")
+	called := false
+	k.GET("/status",
+		func(c *Context) {
+			c.InvokeNext()
+			if !c.IsAborted() {
+				t.Errorf("abort fail")
+			}
+			// 中止后的输出被拒绝
+			c.WriteString(http.StatusOK, "ok")
+		},
+		func(c *Context) {
+			c.AbortWithStatus(http.StatusUnauthorized)
+			c.InvokeNext()
+		},
+		func(c *Context) {
+			called = true
+		},
+	)
+	k.GET("/error",
+		func(c *Context) {
+			c.AbortWithError(http.StatusForbidden, errAuth)
+			if c.Err() != errAuth {
+				t.Errorf("abort with error fail %v", c.Err())
+			}
+		},
+		func(c *Context) {
+			called = true
+		},
+	)
+	// 既不输出也不调用InvokeNext
+	k.GET("/broken", func(c *Context) {}, func(c *Context) {
+		called = true
+	})
+
+	r, _ := http.NewRequest(http.MethodGet, "/status", nil)
+	resp := k.RunTest(r)
+	if resp.StatusCode != http.StatusUnauthorized || readBody(resp) != "" {
+		t.Errorf("abort with status fail %d", resp.StatusCode)
+	}
+	if !strings.Contains(out.String(), "after abort is rejected") {
+		t.Errorf("write after abort not logged %s", out.String())
+	}
+
+	r, _ = http.NewRequest(http.MethodGet, "/error", nil)
+	resp = k.RunTest(r)
+	if resp.StatusCode != http.StatusForbidden || !strings.Contains(readBody(resp), errAuth.Error()) {
+		t.Errorf("abort with error fail %d", resp.StatusCode)
+	}
+
+	r, _ = http.NewRequest(http.MethodGet, "/broken", nil)
+	k.RunTest(r)
+	if !strings.Contains(out.String(), "neither wrote a response nor called InvokeNext") {
+		t.Errorf("broken chain not detected %s", out.String())
+	}
+	if called {
+		t.Errorf("handler after abort called")
+	}
+}
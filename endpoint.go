@@ -67,6 +67,8 @@ func (endpoint endpoint) doPreRun(rootPath string, router *router, handlerList .
 
 	// 合并所有router的handler
 	chain := newHandlerChain()
+	chain.k = router.Kelly()
+	chain.route = urlPath
 	for _, handlers := range handlerList {
 		for _, handler := range handlers {
 			chain.append(handler(annotationContext))
@@ -1,10 +1,15 @@
 package kelly
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
 
 var (
 	// ErrNoContextData 没有Kelly.Context对象
 	ErrNoContextData error = errors.New("kelly context data not exist")
+	// ErrContextDataType Kelly.Context对象类型不匹配
+	ErrContextDataType = errors.New("kelly context data type mismatch")
 	// ErrNoCookie 没有cookie
 	ErrNoCookie = errors.New("request cookie not exist")
 	// ErrInvalidCookie 错误的cookie
@@ -25,6 +30,18 @@ var (
 	ErrInvalidHandler = errors.New("handler is invalid， must be AnnotationHandlerFunc|HandlerFunc")
 	// ErrWriteRespFail 写响应失败
 	ErrWriteRespFail = errors.New("write response fail")
+	// ErrWriteAfterAbort 调用链中止后输出
+	ErrWriteAfterAbort = errors.New("write response after abort")
 	// ErrBindFail bind请求参数（到对象）失败
 	ErrBindFail = errors.New("bind varible fail")
+	// ErrUnsupportedMediaType 不支持请求的Content-Type，同时也是 ErrBindFail
+	ErrUnsupportedMediaType = fmt.Errorf("unsupported media type: %w", ErrBindFail)
+	// ErrBodyTooLarge 请求的body超过了 binding.Binder.MaxBodySize，同时也是 ErrBindFail
+	ErrBodyTooLarge = fmt.Errorf("request body too large: %w", ErrBindFail)
+	// ErrNotHijacker 底层的http.ResponseWriter不支持Hijack
+	ErrNotHijacker = errors.New("response writer is not a http.Hijacker")
+	// ErrUnauthenticated 认证失败
+	ErrUnauthenticated = errors.New("request is not authenticated")
+	// ErrChildNotReady 平滑重启时子进程没有就绪
+	ErrChildNotReady = errors.New("graceful restart child process not ready")
 )
@@ -0,0 +1,87 @@
+package kelly
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+
+	responseBackend "github.com/lixinio/kelly/response"
+)
+
+// File 输出文件，支持 Range/If-Range，以及 Last-Modified/ETag 缓存校验（同 http.ServeContent）
+// 文件不存在时返回404
+func (c *Context) File(filename string) {
+	f, err := os.Open(filename)
+	if err != nil {
+		c.fileNotFound()
+		return
+	}
+	defer f.Close()
+	c.serveFile(f, filepath.Base(filename))
+}
+
+// FileFromFS 从文件系统中输出文件，eg. c.FileFromFS(http.FS(embedFS), "index.html")
+func (c *Context) FileFromFS(fsys http.FileSystem, name string) {
+	f, err := fsys.Open(path.Clean("/" + name))
+	if err != nil {
+		c.fileNotFound()
+		return
+	}
+	defer f.Close()
+	c.serveFile(f, path.Base(name))
+}
+
+// Attachment 以附件方式下载，filename可以包含非ASCII字符
+// reader实现了io.Seeker时支持Range，实现了Stat时输出Last-Modified/ETag，否则直接流式输出
+func (c *Context) Attachment(reader io.Reader, filename string) {
+	c.SetHeader("Content-Disposition", responseBackend.ContentDisposition("attachment", filename))
+
+	if seeker, ok := reader.(io.ReadSeeker); ok {
+		modtime := time.Time{}
+		if stater, ok := reader.(interface{ Stat() (fs.FileInfo, error) }); ok {
+			if fi, err := stater.Stat(); err == nil {
+				modtime = fi.ModTime()
+				c.setETag(fi)
+			}
+		}
+		http.ServeContent(c, c.r, filename, modtime, seeker)
+		return
+	}
+
+	contentType := mime.TypeByExtension(filepath.Ext(filename))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	c.SetHeader("Content-Type", contentType)
+	c.WriteHeader(http.StatusOK)
+	if _, err := io.Copy(c, reader); c.resp.failed(err) {
+		c.Logger().Warn("write attachment fail", "filename", filename, "error", err)
+	}
+}
+
+func (c *Context) serveFile(f http.File, name string) {
+	fi, err := f.Stat()
+	if err != nil || fi.IsDir() {
+		c.fileNotFound()
+		return
+	}
+	c.setETag(fi)
+	http.ServeContent(c, c.r, name, fi.ModTime(), f)
+}
+
+// setETag 没有设置ETag时，根据文件大小和修改时间生成ETag（同nginx）
+func (c *Context) setETag(fi fs.FileInfo) {
+	if c.Header().Get("Etag") == "" {
+		c.Header().Set("Etag", fmt.Sprintf(`"%x-%x"`, fi.Size(), fi.ModTime().UnixNano()))
+	}
+}
+
+func (c *Context) fileNotFound() {
+	c.WriteString(http.StatusNotFound, http.StatusText(http.StatusNotFound))
+}
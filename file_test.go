@@ -0,0 +1,106 @@
+package kelly
+
+import (
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestFile(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "report.txt")
+	if err := os.WriteFile(filename, []byte("0123456789"), 0644); err != nil {
+		t.Fatalf("write file fail %v", err)
+	}
+
+	k := New(&Config{DisableBanner: true})
+	k.GET("/file", func(c *Context) {
+		c.File(filename)
+	})
+	k.GET("/missing", func(c *Context) {
+		c.File(filename + ".missing")
+	})
+	k.GET("/fs/*path", func(c *Context) {
+		c.FileFromFS(http.FS(fstest.MapFS{
+			"a/index.html": &fstest.MapFile{Data: []byte("<p>hi</p>")},
+		}), c.MustGetPathVarible("path"))
+	})
+
+	request := func(path string, headers map[string]string) *http.Response {
+		r, _ := http.NewRequest(http.MethodGet, path, nil)
+		for key, value := range headers {
+			r.Header.Set(key, value)
+		}
+		return k.RunTest(r)
+	}
+
+	resp := request("/file", nil)
+	etag := resp.Header.Get("Etag")
+	if resp.StatusCode != http.StatusOK || readBody(resp) != "0123456789" ||
+		etag == "" || resp.Header.Get("Last-Modified") == "" {
+		t.Errorf("file fail %d|%s", resp.StatusCode, etag)
+	}
+
+	resp = request("/file", map[string]string{"Range": "bytes=2-4"})
+	if resp.StatusCode != http.StatusPartialContent || readBody(resp) != "234" {
+		t.Errorf("file range fail %d", resp.StatusCode)
+	}
+	resp = request("/file", map[string]string{"Range": "bytes=2-4", "If-Range": etag})
+	if resp.StatusCode != http.StatusPartialContent {
+		t.Errorf("file if-range fail %d", resp.StatusCode)
+	}
+	// 文件已经变化，返回完整内容
+	resp = request("/file", map[string]string{"Range": "bytes=2-4", "If-Range": `"old"`})
+	if resp.StatusCode != http.StatusOK || readBody(resp) != "0123456789" {
+		t.Errorf("file if-range change fail %d", resp.StatusCode)
+	}
+	resp = request("/file", map[string]string{"If-None-Match": etag})
+	if resp.StatusCode != http.StatusNotModified {
+		t.Errorf("file if-none-match fail %d", resp.StatusCode)
+	}
+
+	resp = request("/missing", nil)
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("file not found fail %d", resp.StatusCode)
+	}
+
+	resp = request("/fs/a/index.html", nil)
+	if resp.StatusCode != http.StatusOK || readBody(resp) != "<p>hi</p>" || readContentType(resp) != "text/html" {
+		t.Errorf("file from fs fail %d", resp.StatusCode)
+	}
+	resp = request("/fs/a", nil)
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("file from fs dir fail %d", resp.StatusCode)
+	}
+}
+
+func TestAttachment(t *testing.T) {
+	k := New(&Config{DisableBanner: true})
+	k.GET("/seeker", func(c *Context) {
+		c.Attachment(strings.NewReader("0123456789"), "报表 2023.csv")
+	})
+	k.GET("/stream", func(c *Context) {
+		c.Attachment(io.MultiReader(strings.NewReader("a,b\n")), `say "hi".csv`)
+	})
+
+	r, _ := http.NewRequest(http.MethodGet, "/seeker", nil)
+	r.Header.Set("Range", "bytes=0-1")
+	resp := k.RunTest(r)
+	disposition := resp.Header.Get("Content-Disposition")
+	if resp.StatusCode != http.StatusPartialContent || readBody(resp) != "01" ||
+		disposition != `attachment; filename="__ 2023.csv"; filename*=UTF-8''%E6%8A%A5%E8%A1%A8%202023.csv` {
+		t.Errorf("attachment fail %d|%s", resp.StatusCode, disposition)
+	}
+
+	r, _ = http.NewRequest(http.MethodGet, "/stream", nil)
+	resp = k.RunTest(r)
+	disposition = resp.Header.Get("Content-Disposition")
+	if resp.StatusCode != http.StatusOK || readBody(resp) != "a,b" ||
+		disposition != `attachment; filename="say _hi_.csv"` ||
+		readContentType(resp) != "text/csv" {
+		t.Errorf("attachment stream fail %d|%s|%s", resp.StatusCode, disposition, readContentType(resp))
+	}
+}
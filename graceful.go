@@ -0,0 +1,195 @@
+//go:build !windows
+// +build !windows
+
+package kelly
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const (
+	// 子进程继承的监听socket fd
+	envListenerFD = "KELLY_LISTENER_FD"
+	// 子进程通知父进程就绪的管道 fd
+	envReadyFD = "KELLY_READY_FD"
+)
+
+// RunGraceful 启动服务
+// 收到SIGHUP时，重新执行当前程序，并将监听socket传递给子进程，子进程就绪后，当前进程优雅退出
+// 收到SIGINT/SIGTERM时，直接优雅退出
+func (k *kellyImp) RunGraceful(addr string) error {
+	k.tryInit(addr)
+	ln, err := inheritOrListen(addr)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- k.serve(ctx, ln)
+	}()
+
+	if err := notifyReady(); err != nil {
+		k.config.Logger.Error("notify parent process fail", "error", err)
+	}
+
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGHUP, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigs)
+
+	for {
+		select {
+		case err := <-done:
+			return err
+		case sig := <-sigs:
+			if sig == syscall.SIGHUP {
+				if _, err := k.restart(ln); err != nil {
+					// 子进程启动失败，继续服务
+					k.config.Logger.Error("graceful restart fail", "error", err)
+					continue
+				}
+			}
+			cancel()
+			return <-done
+		}
+	}
+}
+
+// inheritOrListen 优先使用父进程传递的监听socket
+func inheritOrListen(addr string) (net.Listener, error) {
+	fd, ok, err := lookupFD(envListenerFD)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return net.Listen("tcp", addr)
+	}
+
+	f := os.NewFile(fd, "kelly-listener")
+	defer f.Close()
+	ln, err := net.FileListener(f)
+	if err != nil {
+		return nil, fmt.Errorf("inherit listener fd(%d) fail: %w", fd, err)
+	}
+	return ln, nil
+}
+
+// notifyReady 通知父进程子进程已经可以接收请求
+func notifyReady() error {
+	fd, ok, err := lookupFD(envReadyFD)
+	if err != nil || !ok {
+		return err
+	}
+
+	f := os.NewFile(fd, "kelly-ready")
+	defer f.Close()
+	_, err = f.Write([]byte{1})
+	return err
+}
+
+// lookupFD 读取并清除环境变量中的fd，避免再传递给孙进程
+func lookupFD(name string) (uintptr, bool, error) {
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		return 0, false, nil
+	}
+	os.Unsetenv(name)
+
+	fd, err := strconv.ParseUint(value, 10, 32)
+	if err != nil {
+		return 0, false, fmt.Errorf("invalid %s(%s): %w", name, value, err)
+	}
+	return uintptr(fd), true, nil
+}
+
+// restart 启动子进程并等待其就绪，返回就绪的子进程
+func (k *kellyImp) restart(ln net.Listener) (*exec.Cmd, error) {
+	filer, ok := ln.(interface{ File() (*os.File, error) })
+	if !ok {
+		return nil, fmt.Errorf("listener %T can NOT be inherited", ln)
+	}
+	lnFile, err := filer.File()
+	if err != nil {
+		return nil, err
+	}
+	defer lnFile.Close()
+
+	readyR, readyW, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+	defer readyR.Close()
+
+	path, err := os.Executable()
+	if err != nil {
+		readyW.Close()
+		return nil, err
+	}
+
+	// ExtraFiles 从 fd 3 开始
+	cmd := exec.Command(path, os.Args[1:]...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.ExtraFiles = []*os.File{lnFile, readyW}
+	cmd.Env = append(
+		filterEnv(os.Environ(), envListenerFD, envReadyFD),
+		envListenerFD+"=3",
+		envReadyFD+"=4",
+	)
+	err = cmd.Start()
+	readyW.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	ready := make(chan error, 1)
+	go func() {
+		buf := make([]byte, 1)
+		_, err := readyR.Read(buf)
+		ready <- err
+	}()
+
+	select {
+	case err := <-ready:
+		if err != nil {
+			// 子进程在就绪前退出
+			cmd.Wait()
+			return nil, fmt.Errorf("child(%d) exit: %w(%s)", cmd.Process.Pid, ErrChildNotReady, err)
+		}
+		return cmd, nil
+	case <-time.After(k.config.ReadyTimeout):
+		cmd.Process.Kill()
+		cmd.Wait()
+		return nil, fmt.Errorf("child(%d) timeout: %w", cmd.Process.Pid, ErrChildNotReady)
+	}
+}
+
+func filterEnv(env []string, names ...string) []string {
+	result := make([]string, 0, len(env))
+	for _, item := range env {
+		skip := false
+		for _, name := range names {
+			if strings.HasPrefix(item, name+"=") {
+				skip = true
+				break
+			}
+		}
+		if !skip {
+			result = append(result, item)
+		}
+	}
+	return result
+}
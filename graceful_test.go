@@ -0,0 +1,144 @@
+//go:build !windows
+// +build !windows
+
+package kelly
+
+import (
+	"errors"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// 平滑重启测试中，子进程（即重新执行的测试程序）的行为
+const envRestartChild = "KELLY_TEST_RESTART_CHILD"
+
+func TestMain(m *testing.M) {
+	if mode, ok := os.LookupEnv(envRestartChild); ok {
+		os.Exit(restartChild(mode))
+	}
+	os.Exit(m.Run())
+}
+
+// restartChild 模拟子进程 ready: 继承socket并就绪; exit: 就绪前退出; hang: 一直不就绪
+func restartChild(mode string) int {
+	switch mode {
+	case "exit":
+		return 1
+	case "hang":
+		time.Sleep(time.Minute)
+		return 0
+	}
+
+	ln, err := inheritOrListen("")
+	if err != nil {
+		return 1
+	}
+	defer ln.Close()
+	if err := notifyReady(); err != nil {
+		return 1
+	}
+	conn, err := ln.Accept()
+	if err != nil {
+		return 1
+	}
+	defer conn.Close()
+	conn.Write([]byte("child"))
+	return 0
+}
+
+// dupFD 复制一份fd，由被测函数负责关闭
+func dupFD(t *testing.T, f *os.File) string {
+	fd, err := syscall.Dup(int(f.Fd()))
+	if err != nil {
+		t.Fatalf("dup fail %v", err)
+	}
+	f.Close()
+	return strconv.Itoa(fd)
+}
+
+func TestInheritListener(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen fail %v", err)
+	}
+	defer ln.Close()
+
+	f, err := ln.(*net.TCPListener).File()
+	if err != nil {
+		t.Fatalf("listener file fail %v", err)
+	}
+	os.Setenv(envListenerFD, dupFD(t, f))
+
+	inherited, err := inheritOrListen("")
+	if err != nil {
+		t.Fatalf("inherit listener fail %v", err)
+	}
+	defer inherited.Close()
+	if inherited.Addr().String() != ln.Addr().String() {
+		t.Errorf("inherit listener addr %s|%s", inherited.Addr(), ln.Addr())
+	}
+	if _, ok := os.LookupEnv(envListenerFD); ok {
+		t.Errorf("%s not cleared", envListenerFD)
+	}
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("pipe fail %v", err)
+	}
+	defer r.Close()
+	os.Setenv(envReadyFD, dupFD(t, w))
+	if err := notifyReady(); err != nil {
+		t.Fatalf("notify ready fail %v", err)
+	}
+	buf := make([]byte, 1)
+	if n, err := r.Read(buf); err != nil || n != 1 {
+		t.Errorf("read ready fail %d|%v", n, err)
+	}
+}
+
+func TestRestart(t *testing.T) {
+	k := New(&Config{DisableBanner: true, ReadyTimeout: time.Millisecond * 500}).(*kellyImp)
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen fail %v", err)
+	}
+
+	// 子进程就绪前退出、就绪超时，均返回 ErrChildNotReady
+	for _, mode := range []string{"exit", "hang"} {
+		t.Setenv(envRestartChild, mode)
+		if _, err := k.restart(ln); !errors.Is(err, ErrChildNotReady) {
+			t.Errorf("restart %s should fail %v", mode, err)
+		}
+	}
+
+	// 子进程继承监听socket，父进程关闭后仍由子进程接收连接
+	t.Setenv(envRestartChild, "ready")
+	cmd, err := k.restart(ln)
+	if err != nil {
+		t.Fatalf("restart fail %v", err)
+	}
+	// 子进程处理完一个连接后退出，测试失败时直接结束
+	t.Cleanup(func() {
+		if t.Failed() {
+			cmd.Process.Kill()
+		}
+		cmd.Wait()
+	})
+	addr := ln.Addr().String()
+	ln.Close()
+
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("dial inherited listener fail %v", err)
+	}
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(time.Second * 5))
+	if data, err := io.ReadAll(conn); err != nil || string(data) != "child" {
+		t.Errorf("inherited listener response %s|%v", data, err)
+	}
+}
@@ -0,0 +1,26 @@
+package kelly
+
+import (
+	"context"
+	"os"
+	"os/signal"
+)
+
+// RunGraceful windows不支持传递监听socket，仅在收到中断信号时优雅退出
+func (k *kellyImp) RunGraceful(addr string) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt)
+	defer signal.Stop(sigs)
+	go func() {
+		select {
+		case <-sigs:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	return k.RunContext(ctx, addr)
+}
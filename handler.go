@@ -15,10 +15,14 @@ type HandlerFunc func(*Context)
 
 type handlerFuncWrap struct {
 	hf HandlerFunc
+	k  Kelly
 }
 
 func (hfw *handlerFuncWrap) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	hfw.hf(newContext(w, r))
+	c := acquireContext(w, r)
+	defer releaseContext(c)
+	c.k = hfw.k
+	hfw.hf(c)
 }
 
 type handlerWrap struct {
@@ -26,7 +30,9 @@ type handlerWrap struct {
 }
 
 func (hw *handlerWrap) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	hw.h.ServeHTTP(newContext(w, r))
+	c := acquireContext(w, r)
+	defer releaseContext(c)
+	hw.h.ServeHTTP(c)
 }
 
 func wrapHttpHandlerFunc(f http.HandlerFunc) HandlerFunc {
@@ -6,25 +6,11 @@ import (
 	"github.com/julienschmidt/httprouter"
 )
 
-type handlerChainEntry struct {
-	current HandlerFunc
-	next    *handlerChainEntry
-}
-
-func (handlerChainEntry *handlerChainEntry) ServeHTTP(c *Context) {
-	if handlerChainEntry.next != nil {
-		c.Set(contextNextHandle, handlerChainEntry.next)
-	} else {
-		c.Set(contextNextHandle, nil)
-	}
-	handlerChainEntry.current(c)
-}
-
 // HandlerChain 调用链， 依次调用
 type HandlerChain struct {
 	handlers []HandlerFunc
-	head     *handlerChainEntry
-	tail     *handlerChainEntry
+	k        Kelly  // 所属Kelly实例
+	route    string // 路由模板 eg. /users/:id
 }
 
 // append 链尾添加回调
@@ -34,17 +20,6 @@ func (handlerChain *HandlerChain) append(handler HandlerFunc) *HandlerChain {
 	}
 
 	handlerChain.handlers = append(handlerChain.handlers, handler)
-	entry := &handlerChainEntry{
-		current: handler,
-		next:    nil,
-	}
-	if handlerChain.head == nil {
-		handlerChain.head = entry
-		handlerChain.tail = entry
-		return handlerChain
-	}
-	handlerChain.tail.next = entry
-	handlerChain.tail = entry
 	return handlerChain
 }
 
@@ -56,24 +31,23 @@ func (handlerChain *HandlerChain) appends(handlers []HandlerFunc) *HandlerChain
 }
 
 func (handlerChain *HandlerChain) ServeHTTP(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
-	if handlerChain.head != nil {
-		// 将Httprouter的接口转换成kelly Context
-		c := newContext(w, r)
-		// 存储path变量
-		c.Set(contextDataKeyPathVarible, params)
-		handlerChain.head.ServeHTTP(c)
+	if len(handlerChain.handlers) == 0 {
+		return
 	}
+
+	// 将Httprouter的接口转换成kelly Context，请求结束后回收
+	c := acquireContext(w, r)
+	defer releaseContext(c)
+
+	c.k = handlerChain.k
+	c.route = handlerChain.route
+	c.params = params
+	c.handlers = handlerChain.handlers
+	c.InvokeNext()
+	c.checkChain()
 }
 
 func newHandlerChain(handlers ...HandlerFunc) *HandlerChain {
-	chain := &HandlerChain{
-		handlers: handlers,
-		head:     nil,
-		tail:     nil,
-	}
-
-	for _, handler := range handlers {
-		chain.append(handler)
-	}
-	return chain
+	chain := &HandlerChain{}
+	return chain.appends(handlers)
 }
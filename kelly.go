@@ -3,12 +3,17 @@ package kelly
 import (
 	"context"
 	"fmt"
+	"html/template"
 	"log"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"os"
 	"strings"
+	"time"
 
 	"github.com/julienschmidt/httprouter"
+	"github.com/lixinio/kelly/binding"
 )
 
 // Config 配置参数
@@ -23,16 +28,45 @@ type Config struct {
 	HandleNotFound HandlerFunc
 	// 调试模式
 	Debug bool
+	// 优雅退出时等待存量连接处理完成的最长时间
+	ShutdownTimeout time.Duration
+	// 平滑重启时等待子进程就绪的最长时间
+	ReadyTimeout time.Duration
+	// 日志，缺省输出到stdout
+	Logger StructuredLogger
+	// 启动时不输出banner
+	DisableBanner bool
+	// html模板，用于 Negotiate，eg. template.Must(template.ParseGlob("templates/*.html"))
+	Templates *template.Template
+	// 响应编码失败（还没有输出任何数据）时的处理，缺省记录日志并返回500
+	HandleError ErrorHandlerFunc
+	// Context.Body 缓存body的最大字节数，缺省 DefaultBodyCacheLimit
+	BodyCacheLimit int64
+	// 绑定请求参数，可以通过 Binder.Register 支持其他Content-Type，以及配置严格模式、json选项、body大小限制等
+	// 缺省 binding.NewBinder()，可以使用 BinderMiddleware 为某些路由单独配置
+	Binder *binding.Binder
 }
 
+// ErrorHandlerFunc 处理请求过程中的错误
+type ErrorHandlerFunc func(*Context, error)
+
+const (
+	defaultShutdownTimeout = 30 * time.Second
+	defaultReadyTimeout    = 10 * time.Second
+)
+
 // Kelly 实例对象
 type Kelly interface {
 	Router
 	http.Handler
 	Run(addr string)                          // 同步启动
 	RunContext(context.Context, string) error // 异步启动，等待context.Done
+	RunGraceful(addr string) error            // 启动，收到SIGHUP时平滑重启，SIGINT/SIGTERM时优雅退出
 	RunTest(r *http.Request) *http.Response   // Debug
 	RegistePreRunHandler(PreRunHandler)       // 注册正式运行前运行逻辑
+	Logger() StructuredLogger                 // 全局日志
+	Provide(key, value interface{})           // 注册全局服务，eg. 数据库连接池
+	Resolve(key interface{}) (interface{}, bool)
 }
 
 type PreRunHandler func(Kelly)
@@ -43,6 +77,7 @@ type kellyImp struct {
 	runBeforeHandlers []PreRunHandler // 监听端口前运行的逻辑
 	config            *Config         //全局配置
 	inited            bool            // 是否已经初始化
+	container         container       // 全局服务
 }
 
 func (k *kellyImp) RegistePreRunHandler(handler PreRunHandler) {
@@ -52,6 +87,10 @@ func (k *kellyImp) RegistePreRunHandler(handler PreRunHandler) {
 	k.runBeforeHandlers = append(k.runBeforeHandlers, handler)
 }
 
+func (k *kellyImp) Logger() StructuredLogger {
+	return k.config.Logger
+}
+
 func (k *kellyImp) tryInit(addr string) {
 	if k.inited {
 		return
@@ -75,21 +114,36 @@ func (k *kellyImp) Run(addr string) {
 
 func (k *kellyImp) RunContext(context context.Context, addr string) error {
 	k.tryInit(addr)
-	srv := &http.Server{Addr: addr, Handler: k.hr}
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		k.config.Logger.Error("listen fail", "addr", addr, "error", err)
+		return err
+	}
+
+	// 优雅退出超时等错误仅返回给调用方，不退出进程
+	if err := k.serve(context, ln); err != nil {
+		k.config.Logger.Error("serve fail", "addr", addr, "error", err)
+		return err
+	}
+	return nil
+}
+
+// serve 在ln上提供服务，ctx结束后停止接收新连接，并等待存量请求处理完成（最长ShutdownTimeout）
+func (k *kellyImp) serve(ctx context.Context, ln net.Listener) error {
+	srv := &http.Server{Handler: k.hr}
+	shutdown := make(chan error, 1)
 
 	go func() {
-		select {
-		case <-context.Done():
-			srv.Shutdown(context)
-			break
-		}
+		<-ctx.Done()
+		timeoutCtx, cancel := context.WithTimeout(context.Background(), k.config.ShutdownTimeout)
+		defer cancel()
+		shutdown <- srv.Shutdown(timeoutCtx)
 	}()
 
-	if err := srv.ListenAndServe(); err != http.ErrServerClosed {
-		log.Fatalf("ListenAndServe(): %v", err)
+	if err := srv.Serve(ln); err != http.ErrServerClosed {
 		return err
 	}
-	return nil
+	return <-shutdown
 }
 
 func defaultHandleMethodNotAllowed(c *Context) {
@@ -100,6 +154,11 @@ func defaultHandleNotFound(c *Context) {
 	c.WriteString(http.StatusNotFound, http.StatusText(http.StatusNotFound))
 }
 
+func defaultHandleError(c *Context, err error) {
+	c.Logger().Error("render response fail", "error", err)
+	c.Abort(http.StatusInternalServerError, "")
+}
+
 func defaultKellyConfig() *Config {
 	return &Config{
 		RedirectTrailingSlash: true,
@@ -118,18 +177,41 @@ func newImp(config *Config, handlers ...interface{}) Kelly {
 	if config.HandleNotFound == nil {
 		config.HandleNotFound = defaultHandleNotFound
 	}
-
-	router.RedirectTrailingSlash = config.RedirectTrailingSlash
-	router.RedirectFixedPath = config.RedirectFixedPath
-	router.NotFound = &handlerFuncWrap{config.HandleNotFound}
-	router.MethodNotAllowed = &handlerFuncWrap{config.HandleMethodNotAllowed}
+	if config.HandleError == nil {
+		config.HandleError = defaultHandleError
+	}
+	if config.Binder == nil {
+		config.Binder = binding.NewBinder()
+	}
+	if config.ShutdownTimeout <= 0 {
+		config.ShutdownTimeout = defaultShutdownTimeout
+	}
+	if config.ReadyTimeout <= 0 {
+		config.ReadyTimeout = defaultReadyTimeout
+	}
+	if config.Logger == nil {
+		level := LevelInfo
+		if config.Debug {
+			level = LevelDebug
+		}
+		config.Logger = NewTextLogger(os.Stdout, level)
+	}
 
 	ky := &kellyImp{
 		hr:                router,
 		config:            config,
 		runBeforeHandlers: make([]PreRunHandler, 0),
 	}
+
+	router.RedirectTrailingSlash = config.RedirectTrailingSlash
+	router.RedirectFixedPath = config.RedirectFixedPath
+	router.NotFound = &handlerFuncWrap{config.HandleNotFound, ky}
+	router.MethodNotAllowed = &handlerFuncWrap{config.HandleMethodNotAllowed, ky}
 	ky.router = newRouterImp(router, ky, nil, "", "", handlers...)
+	ky.Provide(binderKey, config.Binder)
+	if config.Templates != nil {
+		ky.Provide(templatesKey, config.Templates)
+	}
 
 	return ky
 }
@@ -156,7 +238,17 @@ func (k *kellyImp) ServeHTTP(r http.ResponseWriter, w *http.Request) {
 }
 
 func (k *kellyImp) print(addr string) {
-	txt := `
+	if !k.config.DisableBanner {
+		fmt.Println(banner)
+	}
+
+	if strings.HasPrefix(addr, ":") {
+		addr = "127.0.0.1" + addr
+	}
+	k.config.Logger.Info("kelly running", "addr", addr, "debug", k.config.Debug)
+}
+
+const banner = `
 	
 ****************************************************************************
 
@@ -173,11 +265,3 @@ func (k *kellyImp) print(addr string) {
 
 ****************************************************************************
     `
-	fmt.Println(txt)
-	fmt.Printf(" * Debug mode: %v\n", k.config.Debug)
-	if strings.HasPrefix(addr, ":") {
-		fmt.Printf(" * Running on 127.0.0.1%s\n", addr)
-	} else {
-		fmt.Printf(" * Running on %s\n", addr)
-	}
-}
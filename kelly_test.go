@@ -2,6 +2,8 @@ package kelly
 
 import (
 	"context"
+	"errors"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -84,3 +86,34 @@ func TestBreak(t *testing.T) {
 		t.Errorf("k2 return fail %s", err2)
 	}
 }
+
+func TestShutdownTimeout(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen fail %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	started := make(chan struct{})
+	release := make(chan struct{})
+	defer close(release)
+
+	k := New(&Config{DisableBanner: true, ShutdownTimeout: time.Millisecond * 50}).(*kellyImp)
+	k.GET("/slow", func(c *Context) {
+		close(started)
+		<-release
+		c.ResponseStatusOK()
+	})
+	k.tryInit(ln.Addr().String())
+
+	go func() {
+		<-started
+		cancel()
+	}()
+	// 监听已经建立，连接在backlog中等待
+	go http.Get("http://" + ln.Addr().String() + "/slow")
+
+	// 存量请求超时未完成，返回错误（RunContext 直接返回该错误，不退出进程）
+	if err := k.serve(ctx, ln); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("shutdown timeout should return error %v", err)
+	}
+}
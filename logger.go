@@ -0,0 +1,177 @@
+package kelly
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// StructuredLogger 结构化日志接口，keyvals 为交替出现的 key/value
+type StructuredLogger interface {
+	Debug(msg string, keyvals ...interface{})
+	Info(msg string, keyvals ...interface{})
+	Warn(msg string, keyvals ...interface{})
+	Error(msg string, keyvals ...interface{})
+	// With 返回一个附带固定字段的Logger
+	With(keyvals ...interface{}) StructuredLogger
+}
+
+// Level 日志级别
+type Level int
+
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+func (l Level) String() string {
+	switch l {
+	case LevelDebug:
+		return "DEBUG"
+	case LevelInfo:
+		return "INFO"
+	case LevelWarn:
+		return "WARN"
+	default:
+		return "ERROR"
+	}
+}
+
+// textLogger 缺省实现，输出 [Kelly] 时间 | 级别 | 消息 key=value
+type textLogger struct {
+	mu     *sync.Mutex
+	out    io.Writer
+	level  Level
+	fields []interface{}
+}
+
+// NewTextLogger 创建一个输出文本格式的Logger，低于level的日志被忽略
+func NewTextLogger(out io.Writer, level Level) StructuredLogger {
+	return &textLogger{
+		mu:    &sync.Mutex{},
+		out:   out,
+		level: level,
+	}
+}
+
+func (l *textLogger) Debug(msg string, keyvals ...interface{}) {
+	l.log(LevelDebug, msg, keyvals)
+}
+
+func (l *textLogger) Info(msg string, keyvals ...interface{}) {
+	l.log(LevelInfo, msg, keyvals)
+}
+
+func (l *textLogger) Warn(msg string, keyvals ...interface{}) {
+	l.log(LevelWarn, msg, keyvals)
+}
+
+func (l *textLogger) Error(msg string, keyvals ...interface{}) {
+	l.log(LevelError, msg, keyvals)
+}
+
+func (l *textLogger) With(keyvals ...interface{}) StructuredLogger {
+	return &textLogger{
+		mu:     l.mu,
+		out:    l.out,
+		level:  l.level,
+		fields: appendFields(l.fields, keyvals),
+	}
+}
+
+func (l *textLogger) log(level Level, msg string, keyvals []interface{}) {
+	if level < l.level {
+		return
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "[Kelly] %v | %-5s | %s",
+		time.Now().Format("2006/01/02 15:04:05"),
+		level,
+		msg,
+	)
+	writeFields(&b, l.fields)
+	writeFields(&b, keyvals)
+	b.WriteByte('\n')
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	io.WriteString(l.out, b.String())
+}
+
+func writeFields(b *strings.Builder, keyvals []interface{}) {
+	for i := 0; i < len(keyvals); i += 2 {
+		var key, value interface{}
+		if i+1 < len(keyvals) {
+			key, value = keyvals[i], keyvals[i+1]
+		} else {
+			// 落单的value
+			key, value = "!BADKEY", keyvals[i]
+		}
+		fmt.Fprintf(b, " %v=%s", key, formatValue(value))
+	}
+}
+
+func formatValue(value interface{}) string {
+	s := fmt.Sprint(value)
+	if s == "" || strings.ContainsAny(s, " =\"\t\n") {
+		return strconv.Quote(s)
+	}
+	return s
+}
+
+func appendFields(fields, keyvals []interface{}) []interface{} {
+	result := make([]interface{}, 0, len(fields)+len(keyvals))
+	result = append(result, fields...)
+	return append(result, keyvals...)
+}
+
+// SlogLogger 和 *slog.Logger 的方法签名一致，用于适配 log/slog
+type SlogLogger interface {
+	Debug(msg string, args ...interface{})
+	Info(msg string, args ...interface{})
+	Warn(msg string, args ...interface{})
+	Error(msg string, args ...interface{})
+}
+
+type slogLogger struct {
+	l      SlogLogger
+	fields []interface{}
+}
+
+// NewSlogLogger 使用slog风格的logger输出日志，eg. kelly.NewSlogLogger(slog.Default())
+func NewSlogLogger(l SlogLogger) StructuredLogger {
+	return &slogLogger{l: l}
+}
+
+func (l *slogLogger) Debug(msg string, keyvals ...interface{}) {
+	l.l.Debug(msg, appendFields(l.fields, keyvals)...)
+}
+
+func (l *slogLogger) Info(msg string, keyvals ...interface{}) {
+	l.l.Info(msg, appendFields(l.fields, keyvals)...)
+}
+
+func (l *slogLogger) Warn(msg string, keyvals ...interface{}) {
+	l.l.Warn(msg, appendFields(l.fields, keyvals)...)
+}
+
+func (l *slogLogger) Error(msg string, keyvals ...interface{}) {
+	l.l.Error(msg, appendFields(l.fields, keyvals)...)
+}
+
+func (l *slogLogger) With(keyvals ...interface{}) StructuredLogger {
+	return &slogLogger{
+		l:      l.l,
+		fields: appendFields(l.fields, keyvals),
+	}
+}
+
+// defaultLogger 没有关联Kelly实例时使用（例如单元测试直接构造的Context）
+var defaultLogger = NewTextLogger(os.Stdout, LevelInfo)
@@ -0,0 +1,77 @@
+package kelly
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestTextLogger(t *testing.T) {
+	buf := new(bytes.Buffer)
+	logger := NewTextLogger(buf, LevelInfo)
+	logger.Debug("ignored")
+	logger.With("a", 1).Info("hello world", "b", "x y", "c")
+
+	result := buf.String()
+	if strings.Contains(result, "ignored") {
+		t.Errorf("debug log not filtered %s", result)
+	}
+	if !strings.HasSuffix(result, `| INFO  | hello world a=1 b="x y" !BADKEY=c`+"\n") {
+		t.Errorf("text logger format fail %s", result)
+	}
+}
+
+type fakeSlog struct {
+	buf *bytes.Buffer
+}
+
+func (f fakeSlog) Debug(msg string, args ...interface{}) { fmt.Fprintln(f.buf, "debug", msg, args) }
+func (f fakeSlog) Info(msg string, args ...interface{})  { fmt.Fprintln(f.buf, "info", msg, args) }
+func (f fakeSlog) Warn(msg string, args ...interface{})  { fmt.Fprintln(f.buf, "warn", msg, args) }
+func (f fakeSlog) Error(msg string, args ...interface{}) { fmt.Fprintln(f.buf, "error", msg, args) }
+
+func TestContextLogger(t *testing.T) {
+	buf := new(bytes.Buffer)
+	k := New(&Config{
+		Logger:        NewSlogLogger(fakeSlog{buf}),
+		DisableBanner: true,
+	})
+	k.GET("/users/:id", func(c *Context) {
+		c.Logger().Warn("visit", "id", c.MustGetPathVarible("id"))
+		c.ResponseStatusOK()
+	})
+
+	r, _ := http.NewRequest(http.MethodGet, "/users/1", nil)
+	r.Header.Set(HeaderRequestID, "abc")
+	w := httptest.NewRecorder()
+	k.ServeHTTP(w, r)
+
+	expect := "warn visit [request_id abc method GET route /users/:id id 1]"
+	if !strings.Contains(buf.String(), expect) {
+		t.Errorf("context logger fail %s", buf.String())
+	}
+}
+
+func TestLoggerMiddleware(t *testing.T) {
+	buf := new(bytes.Buffer)
+	k := New(&Config{
+		Logger:        NewSlogLogger(fakeSlog{buf}),
+		DisableBanner: true,
+	})
+	// 兼容之前的名称
+	k.GET("/users", Logger, func(c *Context) {
+		c.ResponseStatusOK()
+	})
+
+	r, _ := http.NewRequest(http.MethodGet, "/users?page=1", nil)
+	r.Header.Set(HeaderRequestID, "abc")
+	k.ServeHTTP(httptest.NewRecorder(), r)
+
+	if !strings.Contains(buf.String(), "info request [request_id abc latency ") ||
+		!strings.Contains(buf.String(), " method GET path /users?page=1]") {
+		t.Errorf("logger middleware fail %s", buf.String())
+	}
+}
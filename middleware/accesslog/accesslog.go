@@ -0,0 +1,250 @@
+package accesslog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/lixinio/kelly"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// FormatCommon Apache Common Log Format
+	FormatCommon = `{{dash .RemoteAddr}} - {{dash .User}} [{{.Time.Format "02/Jan/2006:15:04:05 -0700"}}] "{{.Method}} {{.URI}} {{.Proto}}" {{.Status}} {{size .Size}}`
+	// FormatCombined Apache Combined Log Format
+	FormatCombined = FormatCommon + ` "{{dash .Referer}}" "{{dash .UserAgent}}"`
+	// FormatJSON 每行一个json对象
+	FormatJSON = "json"
+)
+
+// Entry 一条访问日志，自定义模板可以引用所有字段
+type Entry struct {
+	Time       time.Time     `json:"time"`
+	Latency    time.Duration `json:"latency"`
+	RemoteAddr string        `json:"remote_addr"`
+	Method     string        `json:"method"`
+	URI        string        `json:"uri"`
+	Proto      string        `json:"proto"`
+	Route      string        `json:"route"`
+	Status     int           `json:"status"`
+	Size       int64         `json:"size"`
+	Referer    string        `json:"referer,omitempty"`
+	UserAgent  string        `json:"user_agent,omitempty"`
+	User       string        `json:"user,omitempty"`
+	RequestID  string        `json:"request_id,omitempty"`
+	TraceID    string        `json:"trace_id,omitempty"`
+	SpanID     string        `json:"span_id,omitempty"`
+}
+
+// UserGetterFunc 从认证中间件的结果中获取用户标识
+type UserGetterFunc func(*kelly.Context) string
+
+type Config struct {
+	Output     io.Writer      // 输出，缺省 os.Stdout，可以使用 RotateWriter
+	Format     string         // FormatCommon|FormatCombined|FormatJSON 或者自定义 text/template
+	UserGetter UserGetterFunc // 获取当前用户，缺省使用 kelly.Context.User
+	// 可信代理的IP或者CIDR eg. 10.0.0.0/8，只有来自可信代理的请求才使用 X-Forwarded-For/X-Real-Ip
+	// 缺省不信任任何代理，使用 RemoteAddr，避免客户端伪造访问日志中的地址
+	TrustedProxies []string
+}
+
+var templateFuncs = template.FuncMap{
+	"dash": func(s string) string {
+		if s == "" {
+			return "-"
+		}
+		return s
+	},
+	"size": func(n int64) string {
+		if n <= 0 {
+			return "-"
+		}
+		return strconv.FormatInt(n, 10)
+	},
+}
+
+type encoder func(*bytes.Buffer, *Entry) error
+
+func newEncoder(format string) encoder {
+	if format == FormatJSON {
+		return func(buf *bytes.Buffer, entry *Entry) error {
+			// Encode 自带换行
+			return json.NewEncoder(buf).Encode(entry)
+		}
+	}
+
+	t := template.Must(template.New("accesslog").Funcs(templateFuncs).Parse(format))
+	return func(buf *bytes.Buffer, entry *Entry) error {
+		if err := t.Execute(buf, entry); err != nil {
+			return err
+		}
+		return buf.WriteByte('\n')
+	}
+}
+
+// AccessLog 访问日志中间件
+func AccessLog(config *Config) kelly.HandlerFunc {
+	if config == nil {
+		config = &Config{}
+	}
+	if config.Output == nil {
+		config.Output = os.Stdout
+	}
+	if config.Format == "" {
+		config.Format = FormatCombined
+	}
+	if config.UserGetter == nil {
+		config.UserGetter = defaultUserGetter
+	}
+
+	log := newLogger(config)
+	return func(c *kelly.Context) {
+		start := time.Now()
+		completed := false
+		// 在defer中记录，调用链panic的请求同样需要记录
+		defer func() {
+			status := c.Status()
+			if !completed && !c.Written() {
+				// panic且没有输出，net/http会直接断开连接
+				status = http.StatusInternalServerError
+			}
+			log(c, start, status)
+		}()
+		c.InvokeNext()
+		completed = true
+	}
+}
+
+func newLogger(config *Config) func(*kelly.Context, time.Time, int) {
+	encode := newEncoder(config.Format)
+	proxies := parseProxies(config.TrustedProxies)
+	mu := &sync.Mutex{}
+	bufPool := sync.Pool{
+		New: func() interface{} {
+			return new(bytes.Buffer)
+		},
+	}
+
+	return func(c *kelly.Context, start time.Time, status int) {
+		r := c.Request()
+		entry := &Entry{
+			Time:       start,
+			Latency:    time.Since(start),
+			RemoteAddr: clientIP(r, proxies),
+			Method:     r.Method,
+			URI:        r.RequestURI,
+			Proto:      r.Proto,
+			Route:      c.Route(),
+			Status:     status,
+			Size:       c.Size(),
+			Referer:    r.Referer(),
+			UserAgent:  r.UserAgent(),
+			RequestID:  c.RequestID(),
+		}
+		if entry.URI == "" {
+			entry.URI = r.URL.RequestURI()
+		}
+		entry.User = config.UserGetter(c)
+		if sc := trace.SpanContextFromContext(r.Context()); sc.IsValid() {
+			entry.TraceID = sc.TraceID().String()
+			entry.SpanID = sc.SpanID().String()
+		}
+
+		buf := bufPool.Get().(*bytes.Buffer)
+		buf.Reset()
+		defer bufPool.Put(buf)
+		if err := encode(buf, entry); err != nil {
+			c.Logger().Error("encode access log fail", "error", err)
+			return
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		if _, err := config.Output.Write(buf.Bytes()); err != nil {
+			c.Logger().Error("write access log fail", "error", err)
+		}
+	}
+}
+
+func defaultUserGetter(c *kelly.Context) string {
+	if p := c.User(); p != nil {
+		return p.Identity()
+	}
+	return ""
+}
+
+// proxies 可信代理的网段
+type proxies []*net.IPNet
+
+func parseProxies(list []string) proxies {
+	result := make(proxies, 0, len(list))
+	for _, item := range list {
+		if !strings.Contains(item, "/") {
+			ip := net.ParseIP(item)
+			if ip == nil {
+				panic(fmt.Errorf("invalid trusted proxy %s", item))
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			result = append(result, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, ipNet, err := net.ParseCIDR(item)
+		if err != nil {
+			panic(fmt.Errorf("invalid trusted proxy %s: %w", item, err))
+		}
+		result = append(result, ipNet)
+	}
+	return result
+}
+
+func (p proxies) contains(addr string) bool {
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return false
+	}
+	for _, ipNet := range p {
+		if ipNet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// clientIP 请求来自可信代理时，使用代理设置的请求头
+// X-Forwarded-For 从右向左跳过可信代理，取第一个不可信的地址
+func clientIP(r *http.Request, trusted proxies) string {
+	remote, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		remote = r.RemoteAddr
+	}
+	if !trusted.contains(remote) {
+		return remote
+	}
+
+	if xff := r.Header.Values("X-Forwarded-For"); len(xff) > 0 {
+		ips := strings.Split(strings.Join(xff, ","), ",")
+		for i := len(ips) - 1; i >= 0; i-- {
+			ip := strings.TrimSpace(ips[i])
+			if i == 0 || !trusted.contains(ip) {
+				return ip
+			}
+		}
+	}
+	if ip := r.Header.Get("X-Real-Ip"); ip != "" {
+		return ip
+	}
+	return remote
+}
@@ -0,0 +1,175 @@
+package accesslog
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lixinio/kelly"
+	"github.com/lixinio/kelly/test"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccessLog(t *testing.T) {
+	f := func(c *kelly.Context) {
+		c.WriteString(http.StatusCreated, "hello")
+	}
+	headers := map[string]string{
+		"User-Agent":      "kelly-test",
+		"X-Forwarded-For": "10.0.0.1, 10.0.0.2",
+	}
+
+	buf := new(bytes.Buffer)
+	m := AccessLog(&Config{
+		Output:     buf,
+		Format:     FormatCombined,
+		UserGetter: func(*kelly.Context) string { return "alice" },
+	})
+	resp := test.KellyFramwork("/", "/?a=b", headers, m, f)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	line := buf.String()
+	// 缺省不信任代理设置的请求头，测试请求没有 RemoteAddr
+	require.True(t, strings.HasPrefix(line, "- - alice ["), line)
+	require.True(t, strings.HasSuffix(line, `] "GET /?a=b HTTP/1.1" 201 5 "-" "kelly-test"`+"\n"), line)
+
+	buf.Reset()
+	m = AccessLog(&Config{Output: buf, Format: FormatJSON})
+	test.KellyFramwork("/", "/", headers, m, f)
+	entry := Entry{}
+	require.Nil(t, json.Unmarshal(buf.Bytes(), &entry))
+	require.Equal(t, http.StatusCreated, entry.Status)
+	require.Equal(t, int64(5), entry.Size)
+	require.Equal(t, "/", entry.Route)
+	require.NotEmpty(t, entry.RequestID)
+
+	buf.Reset()
+	m = AccessLog(&Config{Output: buf, Format: "{{.Method}} {{.Route}} {{.Status}}"})
+	test.KellyFramwork("/", "/", headers, m, f)
+	require.Equal(t, "GET / 201\n", buf.String())
+
+	// panic的请求同样记录
+	buf.Reset()
+	m = AccessLog(&Config{Output: buf, Format: "{{.Method}} {{.Route}} {{.Status}}"})
+	require.Panics(t, func() {
+		test.KellyFramwork("/", "/", headers, m, func(c *kelly.Context) {
+			panic("boom")
+		})
+	})
+	require.Equal(t, "GET / 500\n", buf.String())
+}
+
+func TestClientIP(t *testing.T) {
+	trusted := parseProxies([]string{"10.0.0.0/8", "192.168.1.1"})
+	testcases := []struct {
+		remoteAddr string
+		xff        string
+		realIP     string
+		ip         string
+	}{
+		// 不可信的来源，忽略请求头
+		{"1.2.3.4:5678", "9.9.9.9", "8.8.8.8", "1.2.3.4"},
+		// 从右向左跳过可信代理
+		{"192.168.1.1:5678", "9.9.9.9, 1.2.3.4, 10.0.0.2", "", "1.2.3.4"},
+		{"10.0.0.1:5678", "10.0.0.3, 10.0.0.2", "", "10.0.0.3"},
+		{"10.0.0.1:5678", "", "8.8.8.8", "8.8.8.8"},
+		{"10.0.0.1:5678", "", "", "10.0.0.1"},
+	}
+	for _, testcase := range testcases {
+		r, _ := http.NewRequest(http.MethodGet, "/", nil)
+		r.RemoteAddr = testcase.remoteAddr
+		if testcase.xff != "" {
+			r.Header.Set("X-Forwarded-For", testcase.xff)
+		}
+		if testcase.realIP != "" {
+			r.Header.Set("X-Real-Ip", testcase.realIP)
+		}
+		require.Equal(t, testcase.ip, clientIP(r, trusted), testcase.remoteAddr)
+	}
+	require.Equal(t, "9.9.9.9", clientIP(&http.Request{
+		RemoteAddr: "9.9.9.9:80",
+		Header:     http.Header{"X-Forwarded-For": {"1.1.1.1"}},
+	}, nil))
+	require.Panics(t, func() { parseProxies([]string{"invalid"}) })
+}
+
+func TestRotateWriter(t *testing.T) {
+	dir, err := ioutil.TempDir("", "accesslog")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	current := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	now = func() time.Time { return current }
+	defer func() { now = time.Now }()
+
+	filename := filepath.Join(dir, "access.log")
+	w, err := NewRotateWriter(&RotateConfig{
+		Filename:   filename,
+		MaxSize:    10,
+		Interval:   time.Hour,
+		MaxBackups: 2,
+	})
+	require.Nil(t, err)
+	defer w.Close()
+
+	for _, line := range []string{"123456\n", "abcdef\n", "ABCDEF\n", "xyz\n"} {
+		current = current.Add(time.Second)
+		_, err := w.Write([]byte(line))
+		require.Nil(t, err)
+	}
+	backups, _ := filepath.Glob(filename + ".*")
+	require.Equal(t, 2, len(backups))
+	data, _ := ioutil.ReadFile(filename)
+	require.Equal(t, "xyz\n", string(data))
+
+	// 按时间切分
+	current = current.Add(time.Hour)
+	_, err = w.Write([]byte("next\n"))
+	require.Nil(t, err)
+	data, _ = ioutil.ReadFile(filename)
+	require.Equal(t, "next\n", string(data))
+}
+
+func TestRotateWriterRenameFail(t *testing.T) {
+	dir, err := ioutil.TempDir("", "accesslog")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	current := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	now = func() time.Time { return current }
+	defer func() { now = time.Now }()
+
+	filename := filepath.Join(dir, "access.log")
+	logs := new(bytes.Buffer)
+	w, err := NewRotateWriter(&RotateConfig{
+		Filename: filename,
+		MaxSize:  10,
+		ErrorLog: kelly.NewTextLogger(logs, kelly.LevelWarn),
+	})
+	require.Nil(t, err)
+	defer w.Close()
+
+	// 历史文件路径被非空目录占用，改名失败
+	backup := filename + "." + current.Format(backupTimeFormat)
+	require.Nil(t, os.MkdirAll(filepath.Join(backup, "busy"), 0755))
+
+	_, err = w.Write([]byte("before\n"))
+	require.Nil(t, err)
+	require.NotNil(t, w.Rotate())
+	_, err = w.Write([]byte("after\n"))
+	require.Nil(t, err)
+
+	// 写入时切分失败，记录一次日志，继续写入原文件，到下一个切分点再重试
+	for _, line := range []string{"next\n", "x\n"} {
+		_, err = w.Write([]byte(line))
+		require.Nil(t, err)
+	}
+	require.Equal(t, 1, strings.Count(logs.String(), "rotate log file fail"))
+	data, _ := ioutil.ReadFile(filename)
+	require.Equal(t, "before\nafter\nnext\nx\n", string(data))
+}
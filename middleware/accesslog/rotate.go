@@ -0,0 +1,179 @@
+package accesslog
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lixinio/kelly"
+)
+
+var (
+	// ErrRotateConfig 切分配置错误
+	ErrRotateConfig = errors.New("invalid rotate writer config")
+)
+
+const backupTimeFormat = "20060102-150405.000"
+
+// 便于测试替换
+var now = time.Now
+
+type RotateConfig struct {
+	Filename   string                 // 日志文件路径
+	MaxSize    int64                  // 单个文件最大字节数，0 表示不按大小切分
+	Interval   time.Duration          // 按时间切分的间隔 eg. 24 * time.Hour，0 表示不按时间切分
+	MaxBackups int                    // 保留的历史文件个数，0 表示全部保留
+	ErrorLog   kelly.StructuredLogger // 写入时切分失败的日志，缺省输出到 os.Stderr
+}
+
+// RotateWriter 支持按大小/时间切分的文件 io.Writer
+// 历史文件命名为 Filename.20060102-150405.000
+type RotateWriter struct {
+	config   *RotateConfig
+	mu       sync.Mutex
+	file     *os.File
+	size     int64
+	deadline time.Time // 下次按时间切分的时间点
+}
+
+func NewRotateWriter(config *RotateConfig) (*RotateWriter, error) {
+	if config == nil || config.Filename == "" || config.MaxSize < 0 || config.Interval < 0 {
+		return nil, ErrRotateConfig
+	}
+	if config.ErrorLog == nil {
+		config.ErrorLog = kelly.NewTextLogger(os.Stderr, kelly.LevelWarn)
+	}
+
+	w := &RotateWriter{config: config}
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *RotateWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file == nil {
+		// 上次切分时打开文件失败
+		if err := w.open(); err != nil {
+			return 0, err
+		}
+	}
+	if w.shouldRotate(int64(len(p))) {
+		if err := w.rotate(); err != nil {
+			if w.file == nil {
+				return 0, err
+			}
+			// 切分失败时继续写入原文件，不丢弃日志
+			w.config.ErrorLog.Warn("rotate log file fail", "file", w.config.Filename, "error", err)
+		}
+	}
+
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+// Rotate 立即切分
+func (w *RotateWriter) Rotate() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.rotate()
+}
+
+func (w *RotateWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.file == nil {
+		return nil
+	}
+	return w.file.Close()
+}
+
+func (w *RotateWriter) shouldRotate(n int64) bool {
+	if w.config.MaxSize > 0 && w.size > 0 && w.size+n > w.config.MaxSize {
+		return true
+	}
+	return w.config.Interval > 0 && !now().Before(w.deadline)
+}
+
+func (w *RotateWriter) open() error {
+	if err := os.MkdirAll(filepath.Dir(w.config.Filename), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(w.config.Filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	w.file = f
+	w.size = fi.Size()
+	if w.config.Interval > 0 {
+		w.deadline = now().Truncate(w.config.Interval).Add(w.config.Interval)
+	}
+	return nil
+}
+
+func (w *RotateWriter) rotate() error {
+	if w.file != nil {
+		err := w.file.Close()
+		w.file = nil
+		if err != nil {
+			return err
+		}
+	}
+	backup := w.config.Filename + "." + now().Format(backupTimeFormat)
+	renameErr := os.Rename(w.config.Filename, backup)
+	// 改名失败时重新打开原文件，避免后续写入全部失败
+	if err := w.open(); err != nil {
+		return err
+	}
+	if renameErr != nil {
+		// 到下一个切分点再重试，避免每次写入都重试切分
+		w.size = 0
+		return renameErr
+	}
+	return w.removeBackups()
+}
+
+// removeBackups 删除多余的历史文件
+func (w *RotateWriter) removeBackups() error {
+	if w.config.MaxBackups <= 0 {
+		return nil
+	}
+
+	matches, err := filepath.Glob(w.config.Filename + ".*")
+	if err != nil {
+		return err
+	}
+	var backups []string
+	prefix := w.config.Filename + "."
+	for _, match := range matches {
+		if _, err := time.Parse(backupTimeFormat, strings.TrimPrefix(match, prefix)); err == nil {
+			backups = append(backups, match)
+		}
+	}
+	if len(backups) <= w.config.MaxBackups {
+		return nil
+	}
+
+	// 时间格式保证了字典序即时间序
+	sort.Strings(backups)
+	for _, backup := range backups[:len(backups)-w.config.MaxBackups] {
+		if err := os.Remove(backup); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -6,6 +6,8 @@ import (
 	"crypto/sha256"
 	"crypto/subtle"
 	"encoding/base64"
+	"errors"
+	"fmt"
 	"net/http"
 	"strings"
 
@@ -24,61 +26,71 @@ func secureCompare(given string, actual string) bool {
 const basicRealm = "Authorization Required"
 
 const (
-	// 设置到 kelly.Context的 存储 Key， 适配 CurrentUser
-	contextDataKeyBasicUser string = "middleware.basic.user"
+	// AuthScheme Principal.AuthScheme
+	AuthScheme = "basic"
+)
+
+var (
+	// ErrInvalidAuthorization 用户名或者密码错误
+	ErrInvalidAuthorization = errors.New("invalid basic authorization")
 )
 
 // Basic returns a Handler that authenticates via Basic Auth. Writes a http.StatusUnauthorized
 // if authentication fails.
 func BasicAuth(username string, password string) kelly.HandlerFunc {
-	var siteAuth = base64.StdEncoding.EncodeToString([]byte(username + ":" + password))
-	return func(c *kelly.Context) {
-		if auth, err := c.GetHeader("Authorization"); err == nil {
-			if !secureCompare(auth, "Basic "+siteAuth) {
-				unauthorized(c)
-				return
-			}
-			c.Set(contextDataKeyBasicUser, username)
-		} else {
-			unauthorized(c)
-		}
-	}
+	return kelly.Authenticate(unauthorized, Authenticator(username, password))
 }
 
 // BasicFunc returns a Handler that authenticates via Basic Auth using the provided function.
 // The function should return true for a valid username/password combination.
 func BasicAuthFunc(authfn func(string, string) bool) kelly.HandlerFunc {
-	return func(c *kelly.Context) {
+	return kelly.Authenticate(unauthorized, AuthenticatorFunc(authfn))
+}
+
+// Authenticator 校验固定的用户名密码，可以和其他认证方式一起用于 kelly.Authenticate
+func Authenticator(username string, password string) kelly.Authenticator {
+	var siteAuth = base64.StdEncoding.EncodeToString([]byte(username + ":" + password))
+	return func(c *kelly.Context) (kelly.Principal, error) {
+		auth, err := c.GetHeader("Authorization")
+		if err != nil {
+			return nil, err
+		}
+		if !secureCompare(auth, "Basic "+siteAuth) {
+			return nil, ErrInvalidAuthorization
+		}
+		return kelly.NewPrincipal(AuthScheme, username, username), nil
+	}
+}
+
+// AuthenticatorFunc 使用authfn校验用户名密码
+func AuthenticatorFunc(authfn func(string, string) bool) kelly.Authenticator {
+	return func(c *kelly.Context) (kelly.Principal, error) {
 		auth, err := c.GetHeader("Authorization")
 		if err != nil {
-			unauthorized(c)
-			return
+			return nil, err
 		}
 
 		if len(auth) < 6 || auth[:6] != "Basic " {
-			unauthorized(c)
-			return
+			return nil, ErrInvalidAuthorization
 		}
 		b, err := base64.StdEncoding.DecodeString(auth[6:])
 		if err != nil {
-			unauthorized(c)
-			return
+			return nil, fmt.Errorf("decode fail(%v) : %w", err, ErrInvalidAuthorization)
 		}
 		tokens := strings.SplitN(string(b), ":", 2)
 		if len(tokens) != 2 || !authfn(tokens[0], tokens[1]) {
-			unauthorized(c)
-			return
+			return nil, ErrInvalidAuthorization
 		}
-		c.Set(contextDataKeyBasicUser, tokens[0])
+		return kelly.NewPrincipal(AuthScheme, tokens[0], tokens[0]), nil
 	}
 }
 
-func unauthorized(res http.ResponseWriter) {
-	res.Header().Set("WWW-Authenticate", "Basic realm=\""+basicRealm+"\"")
-	http.Error(res, "Not Authorized", http.StatusUnauthorized)
+func unauthorized(c *kelly.Context, err error) {
+	c.Header().Set("WWW-Authenticate", "Basic realm=\""+basicRealm+"\"")
+	http.Error(c, "Not Authorized", http.StatusUnauthorized)
 }
 
 // CurrentUser 获得当前用户
 func CurrentUser(c *kelly.Context) interface{} {
-	return c.MustGet(contextDataKeyBasicUser)
+	return kelly.PrincipalKey.MustGet(c).Value()
 }
@@ -21,6 +21,9 @@ func TestBasicAuth(t *testing.T) {
 		if CurrentUser(c) != username {
 			t.Errorf("basic auth fail %v|%v", CurrentUser(c), username)
 		}
+		if user := c.User(); user.Identity() != username || user.AuthScheme() != AuthScheme {
+			t.Errorf("basic auth principal fail %v", user)
+		}
 		c.ResponseStatusOK()
 	}
 
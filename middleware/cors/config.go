@@ -79,6 +79,11 @@ func (cors *cors) applyCors(c *kelly.Context) (err error) {
 		c.Abort(http.StatusForbidden, http.StatusText(http.StatusForbidden))
 		return
 	}
+	if cors.explicitOrigin(origin) {
+		// 只有明确允许的Origin，websocket握手才不再重复校验
+		// 允许所有Origin时仍由 Upgrade 校验，避免跨站websocket劫持
+		c.Set(kelly.OriginAllowedKey, true)
+	}
 
 	if c.Request().Method == "OPTIONS" {
 		cors.handlePreflight(c)
@@ -110,9 +115,11 @@ func (cors *cors) validateWildcardOrigin(origin string) bool {
 }
 
 func (cors *cors) validateOrigin(origin string) bool {
-	if cors.allowAllOrigins {
-		return true
-	}
+	return cors.allowAllOrigins || cors.explicitOrigin(origin)
+}
+
+// explicitOrigin Origin是否被列表、通配符或者函数明确允许（不考虑允许所有Origin）
+func (cors *cors) explicitOrigin(origin string) bool {
 	for _, value := range cors.allowOrigins {
 		if value == origin {
 			return true
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/lixinio/kelly"
+	"github.com/lixinio/kelly/websocket"
 )
 
 // Config represents all available options for the middleware.
@@ -165,6 +166,16 @@ func Cors(config Config) kelly.HandlerFunc {
 	}
 }
 
+// CheckOrigin 使用跨域规则校验websocket握手的Origin，用于 websocket.Upgrader.CheckOrigin
+// 只接受同源或者明确允许的Origin，允许所有Origin（AllowAllOrigins/"*"）对websocket不生效，避免跨站websocket劫持
+func CheckOrigin(config Config) func(r *http.Request) bool {
+	cors := newCors(config)
+	return func(r *http.Request) bool {
+		origin := r.Header.Get("Origin")
+		return origin == "" || websocket.SameOrigin(r) || cors.explicitOrigin(origin)
+	}
+}
+
 func DefaultCors() kelly.HandlerFunc {
 	config := DefaultConfig()
 	config.AllowAllOrigins = true
@@ -0,0 +1,69 @@
+package cors
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/lixinio/kelly"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOriginAllowed(t *testing.T) {
+	explicit := DefaultConfig()
+	explicit.AllowWildcard = true
+	explicit.AllowOrigins = []string{"http://allowed.com", "http://*.example.com"}
+
+	testcases := []struct {
+		middleware kelly.HandlerFunc
+		origin     string
+		allowed    bool
+	}{
+		// 允许所有Origin时，websocket握手仍需要校验
+		{DefaultCors(), "http://evil.com", false},
+		{Cors(explicit), "http://allowed.com", true},
+		{Cors(explicit), "http://api.example.com", true},
+	}
+	for _, testcase := range testcases {
+		k := kelly.New(&kelly.Config{DisableBanner: true})
+		allowed := false
+		k.GET("/", testcase.middleware, func(c *kelly.Context) {
+			allowed, _ = kelly.OriginAllowedKey.Get(c)
+			c.ResponseStatusOK()
+		})
+
+		r, _ := http.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("Origin", testcase.origin)
+		w := httptest.NewRecorder()
+		k.ServeHTTP(w, r)
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Equal(t, testcase.allowed, allowed, testcase.origin)
+	}
+}
+
+func TestCheckOrigin(t *testing.T) {
+	all := DefaultConfig()
+	all.AllowAllOrigins = true
+	explicit := DefaultConfig()
+	explicit.AllowOrigins = []string{"*", "http://allowed.com"}
+
+	testcases := []struct {
+		config  Config
+		origin  string
+		allowed bool
+	}{
+		// 允许所有Origin时，只接受同源的握手
+		{all, "http://evil.com", false},
+		{all, "http://example.com", true},
+		{all, "", true},
+		{explicit, "http://allowed.com", true},
+		{explicit, "http://evil.com", false},
+	}
+	for _, testcase := range testcases {
+		r, _ := http.NewRequest(http.MethodGet, "http://example.com/ws", nil)
+		if testcase.origin != "" {
+			r.Header.Set("Origin", testcase.origin)
+		}
+		require.Equal(t, testcase.allowed, CheckOrigin(testcase.config)(r), testcase.origin)
+	}
+}
@@ -3,11 +3,13 @@
 package middleware
 
 import (
+	"bufio"
 	"compress/gzip"
 	"compress/zlib"
 	"fmt"
 	"io"
 	"io/ioutil"
+	"net"
 	"net/http"
 	"path/filepath"
 	"strings"
@@ -36,18 +38,29 @@ var (
 )
 
 func Gzip(level int, method int) kelly.HandlerFunc {
+	if level < BestCompression || level >= MaxCompressionLevel {
+		panic(fmt.Errorf("invalid level %d", level))
+	}
 
 	var gzPool sync.Pool
 	var methodStr = ""
 	if method == GzipMethod {
-		methodStr = gzipWriter{}.Name()
+		methodStr = "gzip"
 		gzPool.New = func() interface{} {
-			return newGzipWriter(level)
+			gz, err := gzip.NewWriterLevel(ioutil.Discard, gzipLevels[level])
+			if err != nil {
+				panic(err)
+			}
+			return &compressWriter{compressor: gz, encoding: "gzip"}
 		}
 	} else if method == DeflateMethod {
-		methodStr = deflateWriter{}.Name()
+		methodStr = "deflate"
 		gzPool.New = func() interface{} {
-			return newDeflateWriter(level)
+			gz, err := zlib.NewWriterLevel(ioutil.Discard, zlibLevels[level])
+			if err != nil {
+				panic(err)
+			}
+			return &compressWriter{compressor: gz, encoding: "deflate"}
 		}
 	} else {
 		panic(fmt.Errorf("invalid method %d", method))
@@ -55,118 +68,173 @@ func Gzip(level int, method int) kelly.HandlerFunc {
 
 	return func(c *kelly.Context) {
 		if !shouldCompress(c.Request(), methodStr) {
+			c.InvokeNext()
 			return
 		}
 
-		gz := gzPool.Get().(compressWriter)
-		defer func() {
-			gz.SetCompressionWriter(ioutil.Discard)
-			gzPool.Put(gz)
-		}()
-		gz.SetCompressionWriter(c.ResponseWriter)
-		gz.SetResponseWriter(c.ResponseWriter)
-
-		c.SetHeader("Content-Encoding", methodStr)
+		gz := gzPool.Get().(*compressWriter)
+		gz.reset(c.ResponseWriter)
+		// Content-Encoding 在输出body时才设置，只有响应头的响应不声明压缩
 		c.SetHeader("Vary", "Accept-Encoding")
 		c.ResponseWriter = gz
 		defer func() {
-			gz.Close()
+			gz.close()
+			c.ResponseWriter = gz.ResponseWriter
+			gz.reset(nil)
+			gzPool.Put(gz)
 		}()
+
+		c.InvokeNext()
 	}
 }
 
 // ---------------------------------------------------------------------------------------------------------------------
 
-type compressWriter interface {
-	http.ResponseWriter
-	SetCompressionWriter(w io.Writer)
-	SetResponseWriter(w http.ResponseWriter)
-	Close()
-	Name() string
+// compressor gzip.Writer/zlib.Writer 的公共接口
+type compressor interface {
+	io.WriteCloser
+	Flush() error
+	Reset(io.Writer)
 }
 
-// ---------------------------------------------------------------------------------------------------------------------
-
-type gzipWriter struct {
+// compressWriter 压缩body，同时透传 http.Flusher/http.Hijacker/http.Pusher/io.ReaderFrom
+// 响应头推迟到输出body（或者结束、Flush）时发送，期间 kelly.Context.Written 返回false
+type compressWriter struct {
 	http.ResponseWriter
-	writer *gzip.Writer
-}
-
-func (g gzipWriter) Name() string {
-	return "gzip"
+	compressor  compressor
+	encoding    string // gzip|deflate
+	code        int    // 推迟发送的响应码
+	written     bool   // 是否写入过body（已经声明压缩）
+	passthrough bool   // 响应头已经发送且没有声明压缩，body不再压缩
+	hijacked    bool
 }
 
-func (g *gzipWriter) SetResponseWriter(w http.ResponseWriter) {
+func (g *compressWriter) reset(w http.ResponseWriter) {
 	g.ResponseWriter = w
+	g.code = 0
+	g.written = false
+	g.passthrough = false
+	g.hijacked = false
+	if w == nil {
+		g.compressor.Reset(ioutil.Discard)
+	} else {
+		g.compressor.Reset(w)
+	}
 }
 
-func (g *gzipWriter) SetCompressionWriter(w io.Writer) {
-	g.writer.Reset(w)
+// close 输出压缩的尾部数据；若没有body，发送推迟的响应头，不声明压缩
+func (g *compressWriter) close() {
+	if g.hijacked {
+		return
+	}
+	if !g.written {
+		g.sendHeader()
+		return
+	}
+	g.compressor.Close()
 }
 
-func (g *gzipWriter) Close() {
-	g.writer.Close()
+// sendHeader 发送推迟的响应头（不声明压缩），之后的body不再压缩
+func (g *compressWriter) sendHeader() {
+	if g.code != 0 {
+		g.ResponseWriter.WriteHeader(g.code)
+		g.code = 0
+		g.passthrough = true
+	}
 }
 
-func (g *gzipWriter) Write(data []byte) (int, error) {
-	return g.writer.Write(data)
+func (g *compressWriter) Unwrap() http.ResponseWriter {
+	return g.ResponseWriter
 }
 
-func newGzipWriter(level int) compressWriter {
-	if level < BestCompression || level >= MaxCompressionLevel {
-		panic(fmt.Errorf("invalid level %d", level))
+func (g *compressWriter) WriteHeader(code int) {
+	if g.written || g.passthrough || g.code != 0 {
+		// 响应头只能发送一次
+		return
 	}
-	gz, err := gzip.NewWriterLevel(ioutil.Discard, gzipLevels[level])
-	if err != nil {
-		panic(err)
+	if code < http.StatusOK {
+		// 1xx 不是最终的响应
+		g.ResponseWriter.WriteHeader(code)
+		return
 	}
-	return &gzipWriter{
-		writer: gz,
+	if !bodyAllowed(code) {
+		g.ResponseWriter.WriteHeader(code)
+		g.passthrough = true
+		return
 	}
+	// 推迟到输出body时决定是否声明压缩
+	g.code = code
 }
 
-// ---------------------------------------------------------------------------------------------------------------------
-type deflateWriter struct {
-	http.ResponseWriter
-	writer *zlib.Writer
-}
-
-func (g deflateWriter) Name() string {
-	return "deflate"
-}
-
-func (g *deflateWriter) SetResponseWriter(w http.ResponseWriter) {
-	g.ResponseWriter = w
-}
-
-func (g *deflateWriter) SetCompressionWriter(w io.Writer) {
-	g.writer.Reset(w)
+func (g *compressWriter) Write(data []byte) (int, error) {
+	if g.passthrough {
+		return g.ResponseWriter.Write(data)
+	}
+	if !g.written {
+		g.written = true
+		header := g.Header()
+		if len(header["Content-Type"]) == 0 {
+			// 避免net/http根据压缩后的数据判断类型
+			header.Set("Content-Type", http.DetectContentType(data))
+		}
+		// 压缩后长度发生了变化
+		header.Del("Content-Length")
+		header.Set("Content-Encoding", g.encoding)
+		code := g.code
+		if code == 0 {
+			code = http.StatusOK
+		}
+		g.code = 0
+		g.ResponseWriter.WriteHeader(code)
+	}
+	return g.compressor.Write(data)
 }
 
-func (g *deflateWriter) Close() {
-	g.writer.Close()
+func (g *compressWriter) ReadFrom(r io.Reader) (int64, error) {
+	return io.Copy(struct{ io.Writer }{g}, r)
 }
 
-func (g *deflateWriter) Write(data []byte) (int, error) {
-	return g.writer.Write(data)
+func (g *compressWriter) Flush() {
+	if g.written {
+		g.compressor.Flush()
+	} else if !g.passthrough {
+		// 还没有body时Flush（eg. SSE建立连接），之后的body不再压缩
+		if g.code == 0 {
+			g.code = http.StatusOK
+		}
+		g.sendHeader()
+	}
+	if flusher, ok := g.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
 }
 
-func newDeflateWriter(level int) compressWriter {
-	if level < BestCompression || level >= MaxCompressionLevel {
-		panic(fmt.Errorf("invalid level %d", level))
+func (g *compressWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := g.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, kelly.ErrNotHijacker
 	}
-
-	gz, err := zlib.NewWriterLevel(ioutil.Discard, zlibLevels[level])
-	if err != nil {
-		panic(err)
+	conn, rw, err := hijacker.Hijack()
+	if err == nil {
+		g.hijacked = true
 	}
-	return &deflateWriter{
-		writer: gz,
+	return conn, rw, err
+}
+
+func (g *compressWriter) Push(target string, opts *http.PushOptions) error {
+	if pusher, ok := g.ResponseWriter.(http.Pusher); ok {
+		return pusher.Push(target, opts)
 	}
+	return http.ErrNotSupported
 }
 
 // ---------------------------------------------------------------------------------------------------------------------
 
+// bodyAllowed 204/304 不能有body
+func bodyAllowed(code int) bool {
+	return code != http.StatusNoContent && code != http.StatusNotModified
+}
+
 func shouldCompress(req *http.Request, method string) bool {
 	if !strings.Contains(req.Header.Get("Accept-Encoding"), method) {
 		return false
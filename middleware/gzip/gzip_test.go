@@ -0,0 +1,116 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/lixinio/kelly"
+	"github.com/lixinio/kelly/test"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGzip(t *testing.T) {
+	body := "hello gzip hello gzip hello gzip"
+	f := func(c *kelly.Context) {
+		c.WriteString(http.StatusOK, body)
+		_, ok := c.ResponseWriter.(http.Flusher)
+		require.True(t, ok)
+	}
+	m := Gzip(DefaultCompression, GzipMethod)
+
+	resp := test.KellyFramwork("/", "/", map[string]string{"Accept-Encoding": "gzip"}, m, f)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "gzip", resp.Header.Get("Content-Encoding"))
+	require.Equal(t, "text/plain; charset=utf-8", resp.Header.Get("Content-Type"))
+	reader, err := gzip.NewReader(resp.Body)
+	require.Nil(t, err)
+	data, err := ioutil.ReadAll(reader)
+	require.Nil(t, err)
+	require.Equal(t, body, string(data))
+
+	// 不支持压缩
+	resp = test.KellyFramwork("/", "/", map[string]string{}, m, f)
+	require.Equal(t, "", resp.Header.Get("Content-Encoding"))
+	data, _ = ioutil.ReadAll(resp.Body)
+	require.Equal(t, body, string(data))
+
+	// 没有body
+	resp = test.KellyFramwork("/", "/", map[string]string{"Accept-Encoding": "gzip"}, m, func(c *kelly.Context) {})
+	require.Equal(t, "", resp.Header.Get("Content-Encoding"))
+}
+
+func TestGzipHijack(t *testing.T) {
+	k := kelly.New(nil)
+	k.GET("/", Gzip(DefaultCompression, GzipMethod), func(c *kelly.Context) {
+		conn, rw, err := c.Hijack()
+		require.Nil(t, err)
+		defer conn.Close()
+		rw.WriteString("HTTP/1.1 200 OK\r\nContent-Length: 2\r\nConnection: close\r\n\r\nok")
+		rw.Flush()
+	})
+	srv := httptest.NewServer(k)
+	defer srv.Close()
+
+	r, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+	r.Header.Set("Accept-Encoding", "gzip")
+	resp, err := http.DefaultTransport.RoundTrip(r)
+	require.Nil(t, err)
+	data, _ := ioutil.ReadAll(resp.Body)
+	require.Equal(t, "ok", string(data))
+}
+
+func TestGzipAbort(t *testing.T) {
+	m := Gzip(DefaultCompression, GzipMethod)
+	f := func(c *kelly.Context) {
+		c.AbortWithError(http.StatusForbidden, errors.New("forbidden"))
+		// 中止后handler的输出被拒绝
+		_, err := c.Write([]byte("more"))
+		require.True(t, errors.Is(err, kelly.ErrWriteAfterAbort))
+	}
+
+	// 压缩数据在调用链返回时输出，不受中止影响
+	resp := test.KellyFramwork("/", "/", map[string]string{"Accept-Encoding": "gzip"}, m, f)
+	require.Equal(t, http.StatusForbidden, resp.StatusCode)
+	require.Equal(t, "gzip", resp.Header.Get("Content-Encoding"))
+	reader, err := gzip.NewReader(resp.Body)
+	require.Nil(t, err)
+	data, err := ioutil.ReadAll(reader)
+	require.Nil(t, err)
+	require.JSONEq(t, `{"code":403,"message":"forbidden"}`, string(data))
+}
+
+func TestGzipHeaderOnly(t *testing.T) {
+	m := Gzip(DefaultCompression, GzipMethod)
+	headers := map[string]string{"Accept-Encoding": "gzip"}
+
+	// 只有响应头时不声明压缩
+	for code, f := range map[int]kelly.HandlerFunc{
+		http.StatusForbidden: func(c *kelly.Context) { c.AbortWithStatus(http.StatusForbidden) },
+		http.StatusAccepted:  func(c *kelly.Context) { c.WriteHeader(http.StatusAccepted) },
+		http.StatusNoContent: func(c *kelly.Context) { c.WriteHeader(http.StatusNoContent) },
+	} {
+		resp := test.KellyFramwork("/", "/", headers, m, f)
+		require.Equal(t, code, resp.StatusCode)
+		require.Equal(t, "", resp.Header.Get("Content-Encoding"))
+		data, _ := ioutil.ReadAll(resp.Body)
+		require.Empty(t, data)
+	}
+
+	// 先发送响应头再输出body，仍然压缩
+	resp := test.KellyFramwork("/", "/", headers, m, func(c *kelly.Context) {
+		c.SetHeader("Content-Length", "5")
+		c.WriteHeader(http.StatusCreated)
+		c.Write([]byte("hello"))
+	})
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	require.Equal(t, "gzip", resp.Header.Get("Content-Encoding"))
+	require.Equal(t, "", resp.Header.Get("Content-Length"))
+	reader, err := gzip.NewReader(resp.Body)
+	require.Nil(t, err)
+	data, _ := ioutil.ReadAll(reader)
+	require.Equal(t, "hello", string(data))
+}
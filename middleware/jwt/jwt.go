@@ -27,8 +27,8 @@ var (
 )
 
 const (
-	// 设置到 kelly.Context的 存储 Key， 适配 CurrentUser
-	contextDataKeyJwtUser string = "middleware.jwt.user"
+	// AuthScheme Principal.AuthScheme
+	AuthScheme = "jwt"
 	// 标准的认证头
 	AuthnHeader string = "Authorization"
 	AuthnType          = "bearer"
@@ -91,23 +91,26 @@ func defaultTokenGetter(c *kelly.Context) (string, error) {
 
 // CurrentUser 获得当前用户
 func CurrentUser(c *kelly.Context) interface{} {
-	return c.MustGet(contextDataKeyJwtUser)
+	return kelly.PrincipalKey.MustGet(c).Value()
 }
 
 func JwtAuth(config *JwtAuthConfig) kelly.HandlerFunc {
 	if config.ErrorHandler == nil {
 		config.ErrorHandler = defaultErrorHandler
 	}
+	return kelly.Authenticate(kelly.AuthErrorHandlerFunc(config.ErrorHandler), Authenticator(config))
+}
 
+// Authenticator 校验jwt token，可以和其他认证方式一起用于 kelly.Authenticate
+func Authenticator(config *JwtAuthConfig) kelly.Authenticator {
 	if config.TokenGetter == nil {
 		config.TokenGetter = defaultTokenGetter
 	}
 
-	return func(c *kelly.Context) {
+	return func(c *kelly.Context) (kelly.Principal, error) {
 		token, err := config.TokenGetter(c)
 		if err != nil {
-			config.ErrorHandler(c, fmt.Errorf("get token fail(%v) : %w", err, ErrGetTokenFail))
-			return
+			return nil, fmt.Errorf("get token fail(%v) : %w", err, ErrGetTokenFail)
 		}
 
 		claims, err := verifyHS256Token(token, config.SecretKey, defaultClaimsGetter())
@@ -119,27 +122,23 @@ func JwtAuth(config *JwtAuthConfig) kelly.HandlerFunc {
 				aerr = ErrTokenExpired
 			}
 
-			config.ErrorHandler(c, fmt.Errorf("verify fail(%v) : %w", err, aerr))
-			return
+			return nil, fmt.Errorf("verify fail(%v) : %w", err, aerr)
 		}
 
 		if len(config.Audience) > 0 {
 			if aud, ok := claims.Get("aud").(string); !ok {
-				config.ErrorHandler(c, fmt.Errorf("claims audience missing : %w", ErrAudienceMissing))
-				return
+				return nil, fmt.Errorf("claims audience missing : %w", ErrAudienceMissing)
 			} else if aud != config.Audience {
-				config.ErrorHandler(c, fmt.Errorf("claims audience dismatch : %w", ErrAudienceDismatch))
-				return
+				return nil, fmt.Errorf("claims audience dismatch : %w", ErrAudienceDismatch)
 			}
 		}
 
 		user, err := config.Authorizator(claims)
 		if err != nil {
-			config.ErrorHandler(c, fmt.Errorf("auth fail(%v) : %w", err, ErrTokenAuthFail))
-			return
+			return nil, fmt.Errorf("auth fail(%v) : %w", err, ErrTokenAuthFail)
 		}
 
-		c.Set(contextDataKeyJwtUser, user)
-		c.InvokeNext()
+		subject, _ := claims.Get("sub").(string)
+		return kelly.NewPrincipal(AuthScheme, subject, user), nil
 	}
 }
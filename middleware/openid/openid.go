@@ -29,8 +29,8 @@ var (
 type MapClaims map[string]interface{}
 
 const (
-	// 设置到 kelly.Context的 存储 Key， 适配 CurrentUser
-	contextDataKeyOpenIDUser string = "middleware.openid.user"
+	// AuthScheme Principal.AuthScheme
+	AuthScheme = "openid"
 	// 标准的认证头
 	AuthnHeader string = "Authorization"
 	AuthnType          = "bearer"
@@ -89,7 +89,7 @@ func defaultTokenGetter(c *kelly.Context) (string, error) {
 
 // CurrentUser 获得当前用户
 func CurrentUser(c *kelly.Context) interface{} {
-	return c.MustGet(contextDataKeyOpenIDUser)
+	return kelly.PrincipalKey.MustGet(c).Value()
 }
 
 func OpenIDAuth(config *OpenIDAuthConfig) (kelly.HandlerFunc, error) {
@@ -97,6 +97,15 @@ func OpenIDAuth(config *OpenIDAuthConfig) (kelly.HandlerFunc, error) {
 		config.ErrorHandler = defaultErrorHandler
 	}
 
+	authenticator, err := Authenticator(config)
+	if err != nil {
+		return nil, err
+	}
+	return kelly.Authenticate(kelly.AuthErrorHandlerFunc(config.ErrorHandler), authenticator), nil
+}
+
+// Authenticator 校验openid token，可以和其他认证方式一起用于 kelly.Authenticate
+func Authenticator(config *OpenIDAuthConfig) (kelly.Authenticator, error) {
 	if config.TokenGetter == nil {
 		config.TokenGetter = defaultTokenGetter
 	}
@@ -110,32 +119,27 @@ func OpenIDAuth(config *OpenIDAuthConfig) (kelly.HandlerFunc, error) {
 		ClientID: config.Audience,
 	})
 
-	return func(c *kelly.Context) {
+	return func(c *kelly.Context) (kelly.Principal, error) {
 		token, err := config.TokenGetter(c)
 		if err != nil {
-			config.ErrorHandler(c, fmt.Errorf("get token fail(%v) : %w", err, ErrGetTokenFail))
-			return
+			return nil, fmt.Errorf("get token fail(%v) : %w", err, ErrGetTokenFail)
 		}
 
 		idtoken, err := verifier.Verify(c.Request().Context(), token)
 		if err != nil {
-			config.ErrorHandler(c, fmt.Errorf("verify fail(%v) : %w", err, ErrTokenVerifyFail))
-			return
+			return nil, fmt.Errorf("verify fail(%v) : %w", err, ErrTokenVerifyFail)
 		}
 
 		var claims MapClaims
 		if err := idtoken.Claims(&claims); err != nil {
-			config.ErrorHandler(c, fmt.Errorf("invalid token chaims fail(%v) : %w", err, ErrTokenInvalidType))
-			return
+			return nil, fmt.Errorf("invalid token chaims fail(%v) : %w", err, ErrTokenInvalidType)
 		}
 
 		user, err := config.Authorizator(&claims)
 		if err != nil {
-			config.ErrorHandler(c, fmt.Errorf("auth fail(%v) : %w", err, ErrTokenAuthFail))
-			return
+			return nil, fmt.Errorf("auth fail(%v) : %w", err, ErrTokenAuthFail)
 		}
 
-		c.Set(contextDataKeyOpenIDUser, user)
-		c.InvokeNext()
+		return kelly.NewPrincipal(AuthScheme, idtoken.Subject, user), nil
 	}, nil
 }
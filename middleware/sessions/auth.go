@@ -15,10 +15,14 @@ var (
 	ErrLMConfigUserGetterError error = errors.New("login manager user getter is empty")
 	// session 管理器为空
 	ErrLMConfigSMError error = errors.New("login manager config session is empty")
+	// 没有登录
+	ErrNotLogin error = errors.New("login manager user not login")
 )
 
 const (
 	SESSION_USER_ID = "user-id"
+	// AuthScheme Principal.AuthScheme
+	AuthScheme = "session"
 )
 
 type User interface {
@@ -83,17 +87,38 @@ func (loginManager *LoginManager) Logout(c *kelly.Context) error {
 }
 
 func (loginManager *LoginManager) GetCurrentUser(c *kelly.Context) interface{} {
-	s, _, err := loginManager.sessionManager.StartSession(c)
+	if p := c.User(); p != nil && p.AuthScheme() == AuthScheme {
+		return p.Value()
+	}
+
+	p, err := loginManager.authenticate(c)
 	if err != nil {
 		return nil
 	}
+	return p.Value()
+}
+
+// Authenticator 根据session识别用户，可以和其他认证方式一起用于 kelly.Authenticate
+func (loginManager *LoginManager) Authenticator() kelly.Authenticator {
+	return loginManager.authenticate
+}
+
+func (loginManager *LoginManager) authenticate(c *kelly.Context) (kelly.Principal, error) {
+	s, _, err := loginManager.sessionManager.StartSession(c)
+	if err != nil {
+		return nil, err
+	}
 
 	userid, ok := s.Values[SESSION_USER_ID]
 	if !ok {
-		return nil
+		return nil, ErrNotLogin
 	}
 
-	return loginManager.config.UserGetter(userid)
+	user := loginManager.config.UserGetter(userid)
+	if user == nil {
+		return nil, ErrNotLogin
+	}
+	return kelly.NewPrincipal(AuthScheme, fmt.Sprint(userid), user), nil
 }
 
 func (loginManager *LoginManager) IsAuthenticated(c *kelly.Context) bool {
@@ -110,7 +135,8 @@ func (loginManager *LoginManager) MustGetCurrentUser(c *kelly.Context) interface
 
 func (loginManager *LoginManager) LoginRequired() kelly.HandlerFunc {
 	return func(c *kelly.Context) {
-		if loginManager.IsAuthenticated(c) {
+		if p, err := loginManager.authenticate(c); err == nil {
+			c.SetUser(p)
 			c.InvokeNext()
 			return
 		}
@@ -0,0 +1,258 @@
+package sse
+
+import (
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/lixinio/kelly"
+)
+
+const (
+	defaultBufferSize = 16
+	defaultMaxTopics  = 1024
+)
+
+type Config struct {
+	BufferSize int              // 每个客户端缓冲的事件数，缓冲满时客户端被踢掉，缺省16
+	Replay     int              // 每个topic保留最近的事件数，用于 Last-Event-ID 续传，0 表示不保留
+	MaxTopics  int              // Replay > 0 时保留的topic数上限，超过时丢弃最久没有发布且没有订阅者的topic，缺省1024
+	SSE        *kelly.SSEConfig // 心跳、重连间隔
+}
+
+// Hub 进程内的SSE发布订阅中心
+type Hub struct {
+	config *Config
+	mu     sync.Mutex
+	seq    uint64
+	topics map[string]*topic
+}
+
+type topic struct {
+	subscribers map[*Subscription]struct{}
+	history     []*entry // 最近的事件，按seq递增
+}
+
+type entry struct {
+	seq   uint64
+	event *kelly.ServerSentEvent
+}
+
+// Subscription 客户端的订阅
+type Subscription struct {
+	hub     *Hub
+	topics  []string
+	events  chan *kelly.ServerSentEvent
+	evicted bool
+	closed  bool
+}
+
+func NewHub(config *Config) *Hub {
+	if config == nil {
+		config = &Config{}
+	}
+	if config.BufferSize <= 0 {
+		config.BufferSize = defaultBufferSize
+	}
+	if config.MaxTopics <= 0 {
+		config.MaxTopics = defaultMaxTopics
+	}
+	return &Hub{
+		config: config,
+		topics: make(map[string]*topic),
+	}
+}
+
+// Publish 发布事件，事件ID为空时自动生成递增的ID
+// 不会阻塞：缓冲已满的客户端会被踢掉，客户端可以通过 Last-Event-ID 重连续传
+func (h *Hub) Publish(name string, event *kelly.ServerSentEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.seq++
+	if event.ID == "" {
+		copied := *event
+		copied.ID = strconv.FormatUint(h.seq, 10)
+		event = &copied
+	}
+
+	t, ok := h.topics[name]
+	if !ok {
+		if h.config.Replay == 0 {
+			// 没有订阅者，也不需要保留历史，不创建topic
+			return
+		}
+		t = h.topic(name)
+		h.trimTopics()
+	}
+	if h.config.Replay > 0 {
+		t.history = append(t.history, &entry{seq: h.seq, event: event})
+		if len(t.history) > h.config.Replay {
+			t.history = t.history[len(t.history)-h.config.Replay:]
+		}
+	}
+
+	for sub := range t.subscribers {
+		select {
+		case sub.events <- event:
+		default:
+			h.evict(sub)
+		}
+	}
+}
+
+// Subscribe 订阅topics，lastEventID不为空时先补发该事件之后的历史事件
+func (h *Hub) Subscribe(lastEventID string, topics ...string) *Subscription {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	backlog := h.backlog(lastEventID, topics)
+	sub := &Subscription{
+		hub:    h,
+		topics: topics,
+		events: make(chan *kelly.ServerSentEvent, h.config.BufferSize+len(backlog)),
+	}
+	for _, e := range backlog {
+		sub.events <- e.event
+	}
+	for _, name := range topics {
+		h.topic(name).subscribers[sub] = struct{}{}
+	}
+	return sub
+}
+
+// Serve 将当前请求订阅到topics，以SSE方式持续输出，直到客户端断开或者被踢掉
+func (h *Hub) Serve(c *kelly.Context, topics ...string) {
+	sub := h.Subscribe(c.LastEventID(), topics...)
+	defer sub.Close()
+
+	if !c.SSE(h.config.SSE, sub.Events()) && sub.Evicted() {
+		c.Logger().Warn("sse slow client evicted", "topics", topics)
+	}
+}
+
+// Handler 订阅固定topics的SSE接口
+func (h *Hub) Handler(topics ...string) kelly.HandlerFunc {
+	return func(c *kelly.Context) {
+		h.Serve(c, topics...)
+	}
+}
+
+// Subscribers 获得topic的订阅者数量
+func (h *Hub) Subscribers(name string) int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if t, ok := h.topics[name]; ok {
+		return len(t.subscribers)
+	}
+	return 0
+}
+
+func (h *Hub) topic(name string) *topic {
+	t, ok := h.topics[name]
+	if !ok {
+		t = &topic{subscribers: make(map[*Subscription]struct{})}
+		h.topics[name] = t
+	}
+	return t
+}
+
+// trimTopics topic数超过上限时，丢弃最久没有发布且没有订阅者的topic，调用者需要持有锁
+func (h *Hub) trimTopics() {
+	if len(h.topics) <= h.config.MaxTopics {
+		return
+	}
+
+	oldest, seq := "", uint64(0)
+	for name, t := range h.topics {
+		// 没有订阅者的topic一定有历史事件
+		if len(t.subscribers) > 0 || len(t.history) == 0 {
+			continue
+		}
+		if last := t.history[len(t.history)-1].seq; oldest == "" || last < seq {
+			oldest, seq = name, last
+		}
+	}
+	if oldest != "" {
+		delete(h.topics, oldest)
+	}
+}
+
+// backlog 查找lastEventID之后的历史事件，找不到lastEventID时不补发
+func (h *Hub) backlog(lastEventID string, topics []string) []*entry {
+	if lastEventID == "" {
+		return nil
+	}
+
+	var last uint64
+	found := false
+	for _, name := range topics {
+		if t, ok := h.topics[name]; ok {
+			for _, e := range t.history {
+				if e.event.ID == lastEventID {
+					last, found = e.seq, true
+				}
+			}
+		}
+	}
+	if !found {
+		return nil
+	}
+
+	var result []*entry
+	for _, name := range topics {
+		if t, ok := h.topics[name]; ok {
+			for _, e := range t.history {
+				if e.seq > last {
+					result = append(result, e)
+				}
+			}
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].seq < result[j].seq
+	})
+	return result
+}
+
+// evict 踢掉客户端，调用者需要持有锁
+func (h *Hub) evict(sub *Subscription) {
+	sub.evicted = true
+	h.remove(sub)
+}
+
+// remove 取消订阅并关闭事件通道，调用者需要持有锁
+func (h *Hub) remove(sub *Subscription) {
+	if sub.closed {
+		return
+	}
+	sub.closed = true
+	for _, name := range sub.topics {
+		if t, ok := h.topics[name]; ok {
+			delete(t.subscribers, sub)
+			if len(t.subscribers) == 0 && len(t.history) == 0 {
+				delete(h.topics, name)
+			}
+		}
+	}
+	close(sub.events)
+}
+
+// Events 事件通道，取消订阅或者被踢掉时关闭
+func (sub *Subscription) Events() <-chan *kelly.ServerSentEvent {
+	return sub.events
+}
+
+// Evicted 是否因为消费太慢被踢掉
+func (sub *Subscription) Evicted() bool {
+	sub.hub.mu.Lock()
+	defer sub.hub.mu.Unlock()
+	return sub.evicted
+}
+
+// Close 取消订阅
+func (sub *Subscription) Close() {
+	sub.hub.mu.Lock()
+	defer sub.hub.mu.Unlock()
+	sub.hub.remove(sub)
+}
@@ -0,0 +1,114 @@
+package sse
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/lixinio/kelly"
+	"github.com/stretchr/testify/require"
+)
+
+func receive(sub *Subscription) []string {
+	var ids []string
+	for {
+		select {
+		case event, ok := <-sub.Events():
+			if !ok {
+				return ids
+			}
+			ids = append(ids, event.ID)
+		default:
+			return ids
+		}
+	}
+}
+
+func TestHub(t *testing.T) {
+	hub := NewHub(&Config{BufferSize: 2, Replay: 3})
+	sub := hub.Subscribe("", "a", "b")
+	require.Equal(t, 1, hub.Subscribers("a"))
+
+	hub.Publish("a", &kelly.ServerSentEvent{Data: "1"})
+	hub.Publish("b", &kelly.ServerSentEvent{Data: "2"})
+	hub.Publish("c", &kelly.ServerSentEvent{Data: "3"})
+	require.Equal(t, []string{"1", "2"}, receive(sub))
+
+	// 缓冲已满，踢掉客户端
+	slow := hub.Subscribe("", "a")
+	for i := 0; i < 3; i++ {
+		hub.Publish("a", &kelly.ServerSentEvent{Data: "x"})
+	}
+	require.True(t, slow.Evicted())
+	require.Equal(t, []string{"4", "5"}, receive(slow))
+	_, ok := <-slow.Events()
+	require.False(t, ok)
+	// sub也没有及时消费
+	require.True(t, sub.Evicted())
+	require.Equal(t, 0, hub.Subscribers("a"))
+
+	// 续传：a只保留最近3个事件
+	replay := hub.Subscribe("4", "a", "b")
+	require.Equal(t, []string{"5", "6"}, receive(replay))
+	replay.Close()
+	// 找不到 Last-Event-ID 时不补发
+	replay = hub.Subscribe("1", "a")
+	require.Empty(t, receive(replay))
+	require.Equal(t, 1, hub.Subscribers("a"))
+	replay.Close()
+	replay.Close()
+	require.Equal(t, 0, hub.Subscribers("a"))
+}
+
+func TestHubTopics(t *testing.T) {
+	// 不保留历史时，没有订阅者的topic不会创建
+	hub := NewHub(nil)
+	for i := 0; i < 10; i++ {
+		hub.Publish(strconv.Itoa(i), &kelly.ServerSentEvent{Data: "x"})
+	}
+	require.Empty(t, hub.topics)
+
+	// 保留历史时，topic数有上限，优先丢弃最久没有发布的topic，有订阅者的topic不会被丢弃
+	hub = NewHub(&Config{Replay: 1, MaxTopics: 2})
+	sub := hub.Subscribe("", "a")
+	defer sub.Close()
+	for _, name := range []string{"a", "b", "c", "d"} {
+		hub.Publish(name, &kelly.ServerSentEvent{Data: name})
+	}
+	require.Equal(t, 2, len(hub.topics))
+	require.Contains(t, hub.topics, "a")
+	require.Contains(t, hub.topics, "d")
+}
+
+func TestHubServe(t *testing.T) {
+	hub := NewHub(&Config{Replay: 10})
+	k := kelly.New(&kelly.Config{DisableBanner: true})
+	k.GET("/events", hub.Handler("news"))
+	srv := httptest.NewServer(k)
+	defer srv.Close()
+
+	hub.Publish("news", &kelly.ServerSentEvent{Data: "old1"})
+	hub.Publish("news", &kelly.ServerSentEvent{Data: "old2"})
+
+	r, _ := http.NewRequest(http.MethodGet, srv.URL+"/events", nil)
+	r.Header.Set(kelly.HeaderLastEventID, "1")
+	resp, err := http.DefaultClient.Do(r)
+	require.Nil(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	hub.Publish("news", &kelly.ServerSentEvent{Event: "news", Data: "hello"})
+	reader := bufio.NewReader(resp.Body)
+	var lines []string
+	for len(lines) < 5 {
+		line, err := reader.ReadString('\n')
+		require.Nil(t, err)
+		if line != "\n" {
+			lines = append(lines, line)
+		}
+	}
+	// 先补发 Last-Event-ID 之后的事件
+	require.Equal(t, []string{"id: 2\n", "data: old2\n", "id: 3\n", "event: news\n", "data: hello\n"}, lines)
+}
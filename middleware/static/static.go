@@ -51,7 +51,7 @@ func Static(config *Config) kelly.HandlerFunc {
 	return func(c *kelly.Context) {
 		// 获得Path变量
 		file := c.MustGetPathVarible("path")
-		fmt.Println("path ", file)
+		c.Logger().Debug("static file", "path", file)
 		f, err := config.Dir.Open(file)
 		if err != nil {
 			handler404(c)
@@ -67,7 +67,7 @@ func Static(config *Config) kelly.HandlerFunc {
 
 		// 处理文件
 		if fi.IsDir() {
-			fmt.Println("serve path ", file)
+			c.Logger().Debug("static dir", "path", file)
 			if len(config.Indexfiles) > 0 {
 				if !serverIndex(config, file, c) {
 					// 如果找不到index， 又支持枚举
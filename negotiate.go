@@ -0,0 +1,77 @@
+package kelly
+
+import (
+	"fmt"
+	"html/template"
+	"mime"
+	"net/http"
+	"strings"
+
+	responseBackend "github.com/lixinio/kelly/response"
+)
+
+// DefaultOffers Negotiate 没有指定offers时使用
+var DefaultOffers = []string{
+	responseBackend.MIMEJSON,
+	responseBackend.MIMEXML,
+	responseBackend.MIMEYAML,
+	responseBackend.MIMEMSGPACK,
+	responseBackend.MIMEPlain,
+}
+
+// templatesKey Config.Templates 在容器中的key，可以在请求级别覆盖
+var templatesKey = NewKey[*template.Template]("kelly.templates")
+
+// OfferHTML 使用名为name的html模板（Config.Templates）渲染，用于 Negotiate
+func OfferHTML(name string) string {
+	return mime.FormatMediaType(responseBackend.MIMEHTML, map[string]string{"template": name})
+}
+
+// Negotiate 根据Accept请求头从offers中选择格式输出data，没有匹配时返回406
+// 支持的格式见 response.Register，html模板使用 OfferHTML，json的其他输出方式使用 response.OfferPureJSON 等
+func (r *responseImp) Negotiate(code int, data interface{}, offers ...string) {
+	if len(offers) == 0 {
+		offers = DefaultOffers
+	}
+	addVary(r.Header(), "Accept")
+
+	offer := responseBackend.Negotiate(r.c.Request().Header.Get("Accept"), offers)
+	if offer == "" {
+		r.WriteString(http.StatusNotAcceptable, http.StatusText(http.StatusNotAcceptable))
+		return
+	}
+
+	mediaType, params, _ := mime.ParseMediaType(offer)
+	if name, ok := params["template"]; ok && mediaType == responseBackend.MIMEHTML {
+		temp, _ := templatesKey.Get(r.c)
+		if temp != nil {
+			temp = temp.Lookup(name)
+		}
+		if temp == nil {
+			panic(fmt.Errorf("negotiate template(%s) not exist, : %w", name, ErrWriteRespFail))
+		}
+		r.WriteTemplateHTML(code, temp, data)
+		return
+	}
+
+	renderer, ok := responseBackend.Lookup(offer)
+	if !ok {
+		panic(fmt.Errorf("negotiate renderer(%s) not exist, : %w", offer, ErrWriteRespFail))
+	}
+	if err := renderer(r, r.c.Request(), code, data); r.failed(err) {
+		panic(fmt.Errorf("negotiate(%s) fail, : %w(%s)", mediaType, ErrWriteRespFail, err))
+	}
+}
+
+// addVary 添加Vary响应头，已经存在时不重复添加（多次协商，或者中间件已经添加）
+func addVary(header http.Header, name string) {
+	for _, value := range header.Values("Vary") {
+		for _, item := range strings.Split(value, ",") {
+			item = strings.TrimSpace(item)
+			if item == "*" || strings.EqualFold(item, name) {
+				return
+			}
+		}
+	}
+	header.Add("Vary", name)
+}
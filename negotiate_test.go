@@ -0,0 +1,66 @@
+package kelly
+
+import (
+	"html/template"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestNegotiate(t *testing.T) {
+	k := New(&Config{
+		DisableBanner: true,
+		Templates:     template.Must(template.New("user.html").Parse(`<b>{{.name}}</b>`)),
+	})
+	data := H{"name": "kelly"}
+	k.GET("/", func(c *Context) {
+		c.Negotiate(http.StatusOK, data)
+	})
+	k.GET("/escape", func(c *Context) {
+		c.SetHeader("Vary", "Origin, accept")
+		c.Negotiate(http.StatusOK, "<script>", "text/html")
+	})
+	k.GET("/html", func(c *Context) {
+		c.Negotiate(http.StatusOK, data, OfferHTML("user.html"), "application/json")
+	})
+
+	testcases := []struct {
+		path        string
+		accept      string
+		code        int
+		contentType string
+		body        string
+	}{
+		{"/", "", http.StatusOK, "application/json", `{"name":"kelly"}`},
+		{"/", "application/xml", http.StatusOK, "application/xml", "<map><name>kelly</name></map>"},
+		{"/", "text/html, application/yaml;q=0.9, application/json;q=0.8", http.StatusOK, "application/yaml", "name: kelly"},
+		// 更具体的匹配项优先
+		{"/", "application/*;q=0.1, application/xml;q=0, text/plain;q=0.5", http.StatusOK, "text/plain", "map[name:kelly]"},
+		{"/", "image/png", http.StatusNotAcceptable, "text/plain", http.StatusText(http.StatusNotAcceptable)},
+		{"/html", "text/html,*/*;q=0.8", http.StatusOK, "text/html", "<b>kelly</b>"},
+		{"/html", "application/json", http.StatusOK, "application/json", `{"name":"kelly"}`},
+	}
+	for _, testcase := range testcases {
+		r, _ := http.NewRequest(http.MethodGet, testcase.path, nil)
+		if testcase.accept != "" {
+			r.Header.Set("Accept", testcase.accept)
+		}
+		resp := k.RunTest(r)
+		body := strings.TrimSpace(readBody(resp))
+		if resp.StatusCode != testcase.code ||
+			readContentType(resp) != testcase.contentType ||
+			body != testcase.body ||
+			resp.Header.Get("Vary") != "Accept" {
+			t.Errorf("negotiate fail %s|%d|%s|%s", testcase.accept, resp.StatusCode, readContentType(resp), body)
+		}
+	}
+	// 没有模板的html需要转义，Vary已经包含Accept时不重复添加
+	r, _ := http.NewRequest(http.MethodGet, "/escape", nil)
+	resp := k.RunTest(r)
+	if body := readBody(resp); body != "&lt;script&gt;" {
+		t.Errorf("negotiate html not escaped %s", body)
+	}
+	if vary := resp.Header.Values("Vary"); len(vary) != 1 || vary[0] != "Origin, accept" {
+		t.Errorf("negotiate duplicate vary %v", vary)
+	}
+}
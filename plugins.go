@@ -1,20 +1,20 @@
 package kelly
 
 import (
-	"fmt"
 	"time"
 )
 
+// LoggerRouter 注册路由时输出日志
 func LoggerRouter(ac *AnnotationContext) HandlerFunc {
-	fmt.Printf("[Kelly] %v | %8s %s\n",
-		time.Now().Format("2006/01/02 15:04:05"),
-		ac.Method,
-		ac.Path,
+	ac.Router.Kelly().Logger().Info("route",
+		"method", ac.Method,
+		"path", ac.Path,
 	)
 	return nil
 }
 
-func Logger(c *Context) {
+// LoggerMiddleware 输出每个请求的耗时
+func LoggerMiddleware(c *Context) {
 	start := time.Now()
 	path := c.Request().URL.Path
 	raw := c.Request().URL.RawQuery
@@ -26,10 +26,16 @@ func Logger(c *Context) {
 		path = path + "?" + raw
 	}
 
-	fmt.Printf("[Kelly] %v | %13s | %s %s\n",
-		time.Now().Format("2006/01/02 15:04:05"),
-		latency,
-		c.Request().Method,
-		path,
+	// method作为独立字段输出，请求级别的日志已经预置了method，这里使用全局日志避免重复
+	c.baseLogger().Info("request",
+		"request_id", c.RequestID(),
+		"latency", latency,
+		"method", c.Request().Method,
+		"path", path,
 	)
 }
+
+// Logger 输出每个请求的耗时
+//
+// Deprecated: 使用 LoggerMiddleware
+var Logger = LoggerMiddleware
@@ -0,0 +1,129 @@
+package kelly
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Principal 认证通过的用户，由各个认证中间件（jwt/openid/basic_auth/sessions）设置
+type Principal interface {
+	Identity() string   // 用户标识 eg. 用户名、sub
+	AuthScheme() string // 认证方式 eg. basic、jwt
+	Value() interface{} // 认证中间件得到的原始用户对象
+}
+
+// PrincipalKey 当前用户在Context中的存储key
+var PrincipalKey = NewKey[Principal]("kelly.principal")
+
+type principal struct {
+	identity string
+	scheme   string
+	value    interface{}
+}
+
+func (p *principal) Identity() string {
+	return p.identity
+}
+
+func (p *principal) AuthScheme() string {
+	return p.scheme
+}
+
+func (p *principal) Value() interface{} {
+	return p.value
+}
+
+// schemePrincipal 替换已有Principal的认证方式
+type schemePrincipal struct {
+	Principal
+	scheme string
+}
+
+func (p *schemePrincipal) AuthScheme() string {
+	return p.scheme
+}
+
+// NewPrincipal 创建Principal
+// 若value本身已经实现了Principal，Identity/Value 使用value的（忽略identity），AuthScheme 使用scheme（为空时使用value的）
+func NewPrincipal(scheme, identity string, value interface{}) Principal {
+	if p, ok := value.(Principal); ok {
+		if scheme == "" || scheme == p.AuthScheme() {
+			return p
+		}
+		return &schemePrincipal{Principal: p, scheme: scheme}
+	}
+	return &principal{
+		identity: identity,
+		scheme:   scheme,
+		value:    value,
+	}
+}
+
+// User 获得当前用户，没有认证时返回nil
+func (c *Context) User() Principal {
+	p, _ := PrincipalKey.Get(c)
+	return p
+}
+
+// SetUser 设置当前用户
+func (c *Context) SetUser(p Principal) *Context {
+	c.Set(PrincipalKey, p)
+	return c
+}
+
+// Authenticator 从请求中识别用户，失败时返回error
+type Authenticator func(*Context) (Principal, error)
+
+// AuthErrorHandlerFunc 认证失败的处理函数
+type AuthErrorHandlerFunc func(*Context, error)
+
+// AuthenticateError 所有Authenticator都失败时，记录每个Authenticator的错误
+type AuthenticateError struct {
+	Errors []error
+}
+
+func (e *AuthenticateError) Error() string {
+	msgs := make([]string, 0, len(e.Errors))
+	for _, err := range e.Errors {
+		msgs = append(msgs, err.Error())
+	}
+	return ErrUnauthenticated.Error() + ": " + strings.Join(msgs, "; ")
+}
+
+func (e *AuthenticateError) Unwrap() error {
+	return ErrUnauthenticated
+}
+
+func defaultAuthErrorHandler(c *Context, err error) {
+	c.WriteString(http.StatusUnauthorized, http.StatusText(http.StatusUnauthorized))
+}
+
+// Authenticate 依次尝试authenticators，第一个成功的设置当前用户并继续调用链
+// 全部失败时调用errorHandler（缺省返回401），只有一个authenticator时直接传递它的错误
+func Authenticate(errorHandler AuthErrorHandlerFunc, authenticators ...Authenticator) HandlerFunc {
+	if len(authenticators) == 0 {
+		panic("at least one Authenticator is required")
+	}
+	if errorHandler == nil {
+		errorHandler = defaultAuthErrorHandler
+	}
+
+	return func(c *Context) {
+		var errs []error
+		for _, authenticator := range authenticators {
+			p, err := authenticator(c)
+			if err == nil {
+				c.SetUser(p)
+				c.InvokeNext()
+				return
+			}
+			errs = append(errs, err)
+		}
+
+		if len(errs) == 1 {
+			errorHandler(c, errs[0])
+			return
+		}
+		errorHandler(c, &AuthenticateError{Errors: errs})
+	}
+}
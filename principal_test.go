@@ -0,0 +1,79 @@
+package kelly
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func headerAuthenticator(header, scheme string) Authenticator {
+	return func(c *Context) (Principal, error) {
+		value, err := c.GetHeader(header)
+		if err != nil {
+			return nil, err
+		}
+		return NewPrincipal(scheme, value, value), nil
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+	var authErr error
+	middleware := Authenticate(
+		func(c *Context, err error) {
+			authErr = err
+			c.WriteString(http.StatusUnauthorized, "fail")
+		},
+		headerAuthenticator("X-Token", "token"),
+		headerAuthenticator("X-Key", "key"),
+	)
+	handler := func(c *Context) {
+		if c.User() == nil {
+			t.Errorf("authenticate fail, no principal")
+			return
+		}
+		c.WriteString(http.StatusOK, c.User().AuthScheme()+":"+c.User().Identity())
+	}
+
+	testcases := []struct {
+		headers map[string]string
+		code    int
+		body    string
+	}{
+		{map[string]string{"X-Token": "a", "X-Key": "b"}, http.StatusOK, "token:a"},
+		{map[string]string{"X-Key": "b"}, http.StatusOK, "key:b"},
+		{map[string]string{}, http.StatusUnauthorized, "fail"},
+	}
+	k := New(nil)
+	k.GET("/", middleware, handler)
+	for _, testcase := range testcases {
+		r, _ := http.NewRequest(http.MethodGet, "/", nil)
+		for key, value := range testcase.headers {
+			r.Header.Set(key, value)
+		}
+		resp := k.RunTest(r)
+		if resp.StatusCode != testcase.code || readBody(resp) != testcase.body {
+			t.Errorf("authenticate fail %d|%v", resp.StatusCode, testcase.headers)
+		}
+	}
+
+	var ae *AuthenticateError
+	if !errors.As(authErr, &ae) || len(ae.Errors) != 2 || !errors.Is(authErr, ErrUnauthenticated) {
+		t.Errorf("authenticate error fail %v", authErr)
+	}
+}
+
+func TestPrincipal(t *testing.T) {
+	p := NewPrincipal("basic", "alice", 1)
+	if p.Identity() != "alice" || p.AuthScheme() != "basic" || p.Value() != 1 {
+		t.Errorf("principal fail %v", p)
+	}
+	// 已经实现Principal的用户对象直接使用
+	if NewPrincipal("basic", "bob", p) != p || NewPrincipal("", "bob", p) != p {
+		t.Errorf("principal wrap fail")
+	}
+	// 认证方式不同时使用指定的scheme
+	wrapped := NewPrincipal("jwt", "bob", p)
+	if wrapped.Identity() != "alice" || wrapped.AuthScheme() != "jwt" || wrapped.Value() != 1 {
+		t.Errorf("principal scheme fail %s|%s|%v", wrapped.Identity(), wrapped.AuthScheme(), wrapped.Value())
+	}
+}
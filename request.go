@@ -5,8 +5,6 @@ import (
 	"mime/multipart"
 	"net/http"
 	"net/url"
-
-	"github.com/julienschmidt/httprouter"
 )
 
 type request interface {
@@ -120,8 +118,7 @@ func (r requestImp) ContentType() string {
 }
 
 func (r requestImp) GetPathVarible(name string) (string, error) {
-	params := r.Context.MustGet(contextDataKeyPathVarible).(httprouter.Params)
-	val := params.ByName(name)
+	val := r.Context.params.ByName(name)
 	if len(val) > 0 {
 		return val, nil
 	}
@@ -251,10 +248,3 @@ func (r requestImp) getPostFormArray(key string) ([]string, bool) {
 	}
 	return []string{}, false
 }
-
-func newRequest(c *Context, r *http.Request) request {
-	return &requestImp{
-		Context: c,
-		Request: r,
-	}
-}
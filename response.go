@@ -2,6 +2,7 @@ package kelly
 
 import (
 	"encoding/xml"
+	"errors"
 	"fmt"
 	"html/template"
 	"io"
@@ -28,13 +29,30 @@ type response interface {
 	WriteData(int, string, []byte)
 	// 返回紧凑的json，直接从二进制读数据
 	WriteRawJSON(int, []byte)
+	// 返回yaml
+	WriteYAML(int, interface{})
+	// 返回msgpack，没有msgpack tag的字段使用json tag
+	WriteMsgPack(int, interface{})
+	// 返回protobuf，数据必须实现 proto.Message
+	WriteProtoBuf(int, interface{})
+	// 返回jsonp，回调函数名来自请求参数 callback，不合法时返回400
+	WriteJSONP(int, interface{})
+	// 返回json，数组添加 while(1); 前缀
+	WriteSecureJSON(int, interface{})
+	// 返回json，非ASCII字符转义
+	WriteASCIIJSON(int, interface{})
+	// 返回json，不转义html字符
+	WritePureJSON(int, interface{})
 	// 返回重定向
 	Redirect(int, string)
+	// 根据Accept请求头选择格式
+	Negotiate(int, interface{}, ...string)
 	// 设置header
 	SetHeader(string, string)
 	// 设置cookie
 	SetCookie(string, string, int, string, string, bool, bool)
 
+	// 返回json格式的错误，并中止调用链
 	Abort(int, string)
 	ResponseStatusOK()
 	ResponseStatusBadRequest(error)
@@ -46,8 +64,6 @@ type response interface {
 
 type responseImp struct {
 	http.ResponseWriter
-	http.Hijacker
-	http.Flusher
 	c *Context
 }
 
@@ -74,6 +90,19 @@ func (r *responseImp) SetCookie(
 	})
 }
 
+// failed 输出是否失败，调用链中止后的输出已经被拒绝并记录日志，不再报错
+// 编码失败时还没有输出任何数据，交给 Config.HandleError 处理
+func (r *responseImp) failed(err error) bool {
+	if err == nil || errors.Is(err, ErrWriteAfterAbort) {
+		return false
+	}
+	if errors.Is(err, responseBackend.ErrRenderFail) && !r.c.Written() {
+		r.c.handleError(err)
+		return false
+	}
+	return true
+}
+
 func (r *responseImp) SetHeader(key, value string) {
 	if len(value) == 0 {
 		r.Header().Del(key)
@@ -83,60 +112,110 @@ func (r *responseImp) SetHeader(key, value string) {
 }
 
 func (r *responseImp) WriteJSON(code int, obj interface{}) {
-	if err := responseBackend.WriteJSON(r, code, obj); err != nil {
+	if err := responseBackend.WriteJSON(r, code, obj); r.failed(err) {
 		panic(fmt.Errorf("write json fail, : %w(%s)", ErrWriteRespFail, err))
 	}
 }
 
 func (r *responseImp) WriteRawJSON(code int, content []byte) {
-	if err := responseBackend.WriteRawJSON(r, code, content); err != nil {
+	if err := responseBackend.WriteRawJSON(r, code, content); r.failed(err) {
 		panic(fmt.Errorf("write raw json fail, : %w(%s)", ErrWriteRespFail, err))
 	}
 }
 
+func (r *responseImp) WriteJSONP(code int, obj interface{}) {
+	if err := responseBackend.RenderJSONP(r, r.c.Request(), code, obj); r.failed(err) {
+		panic(fmt.Errorf("write jsonp fail, : %w(%s)", ErrWriteRespFail, err))
+	}
+}
+
+func (r *responseImp) WriteSecureJSON(code int, obj interface{}) {
+	if err := responseBackend.WriteSecureJSON(r, code, obj); r.failed(err) {
+		panic(fmt.Errorf("write secure json fail, : %w(%s)", ErrWriteRespFail, err))
+	}
+}
+
+func (r *responseImp) WriteASCIIJSON(code int, obj interface{}) {
+	if err := responseBackend.WriteASCIIJSON(r, code, obj); r.failed(err) {
+		panic(fmt.Errorf("write ascii json fail, : %w(%s)", ErrWriteRespFail, err))
+	}
+}
+
+func (r *responseImp) WritePureJSON(code int, obj interface{}) {
+	if err := responseBackend.WritePureJSON(r, code, obj); r.failed(err) {
+		panic(fmt.Errorf("write pure json fail, : %w(%s)", ErrWriteRespFail, err))
+	}
+}
+
+func (r *responseImp) WriteYAML(code int, obj interface{}) {
+	if err := responseBackend.WriteYAML(r, code, obj); r.failed(err) {
+		panic(fmt.Errorf("write yaml fail, : %w(%s)", ErrWriteRespFail, err))
+	}
+}
+
+func (r *responseImp) WriteMsgPack(code int, obj interface{}) {
+	if err := responseBackend.WriteMsgPack(r, code, obj); r.failed(err) {
+		panic(fmt.Errorf("write msgpack fail, : %w(%s)", ErrWriteRespFail, err))
+	}
+}
+
+func (r *responseImp) WriteProtoBuf(code int, obj interface{}) {
+	if err := responseBackend.WriteProtoBuf(r, code, obj); r.failed(err) {
+		panic(fmt.Errorf("write protobuf fail, : %w(%s)", ErrWriteRespFail, err))
+	}
+}
+
 func (r *responseImp) WriteIndentedJSON(code int, obj interface{}) {
-	if err := responseBackend.WriteIndentedJSON(r, code, obj); err != nil {
+	if err := responseBackend.WriteIndentedJSON(r, code, obj); r.failed(err) {
 		panic(fmt.Errorf("write indented json fail, : %w(%s)", ErrWriteRespFail, err))
 	}
 }
 
 func (r *responseImp) WriteHTML(code int, data string) {
-	if err := responseBackend.WriteHTML(r, code, data); err != nil {
+	if err := responseBackend.WriteHTML(r, code, data); r.failed(err) {
 		panic(fmt.Errorf("write html fail, : %w(%s)", ErrWriteRespFail, err))
 	}
 }
 
 func (r *responseImp) WriteTemplateHTML(code int, temp *template.Template, data interface{}) {
-	if err := responseBackend.WriteTemplateHTML(r, code, temp, data); err != nil {
+	if err := responseBackend.WriteTemplateHTML(r, code, temp, data); r.failed(err) {
 		panic(fmt.Errorf("write template fail, : %w(%s)", ErrWriteRespFail, err))
 	}
 }
 
 func (r *responseImp) WriteXML(code int, obj interface{}) {
-	if err := responseBackend.WriteXML(r, code, obj); err != nil {
+	if err := responseBackend.WriteXML(r, code, obj); r.failed(err) {
 		panic(fmt.Errorf("write xml fail, : %w(%s)", ErrWriteRespFail, err))
 	}
 }
 
 func (r *responseImp) WriteString(code int, format string, values ...interface{}) {
-	if err := responseBackend.WriteString(r, code, format, values); err != nil {
+	if err := responseBackend.WriteString(r, code, format, values); r.failed(err) {
 		panic(fmt.Errorf("write string fail, : %w(%s)", ErrWriteRespFail, err))
 	}
 }
 
 func (r *responseImp) Redirect(code int, location string) {
-	if err := responseBackend.Redirect(r, code, r.c.Request(), location); err != nil {
+	if err := responseBackend.Redirect(r, code, r.c.Request(), location); r.failed(err) {
 		panic(fmt.Errorf("redirect (%d|%s) fail, : %w(%s)", code, location, ErrWriteRespFail, err))
 	}
 }
 
 func (r *responseImp) WriteData(code int, contentType string, data []byte) {
-	if err := responseBackend.WriteData(r, code, contentType, data); err != nil {
+	if err := responseBackend.WriteData(r, code, contentType, data); r.failed(err) {
 		panic(fmt.Errorf("write data fail, : %w(%s)", ErrWriteRespFail, err))
 	}
 }
 
 func (r *responseImp) Abort(code int, msg string) {
+	if r.c.IsAborted() {
+		return
+	}
+	r.writeStatus(code, msg)
+	r.c.abort()
+}
+
+func (r *responseImp) writeStatus(code int, msg string) {
 	if code == http.StatusNoContent {
 		r.ResponseWriter.WriteHeader(code)
 		return
@@ -152,50 +231,41 @@ func (r *responseImp) Abort(code int, msg string) {
 }
 
 func (r *responseImp) ResponseStatusOK() {
-	r.Abort(http.StatusOK, "")
+	r.writeStatus(http.StatusOK, "")
 }
 func (r *responseImp) ResponseStatusBadRequest(err error) {
 	if err != nil {
-		r.Abort(http.StatusBadRequest, err.Error())
+		r.writeStatus(http.StatusBadRequest, err.Error())
 	} else {
-		r.Abort(http.StatusBadRequest, "")
+		r.writeStatus(http.StatusBadRequest, "")
 	}
 }
 func (r *responseImp) ResponseStatusUnauthorized(err error) {
 	if err != nil {
-		r.Abort(http.StatusUnauthorized, err.Error())
+		r.writeStatus(http.StatusUnauthorized, err.Error())
 	} else {
-		r.Abort(http.StatusUnauthorized, "")
+		r.writeStatus(http.StatusUnauthorized, "")
 	}
 }
 func (r *responseImp) ResponseStatusForbidden(err error) {
 	if err != nil {
-		r.Abort(http.StatusForbidden, err.Error())
+		r.writeStatus(http.StatusForbidden, err.Error())
 	} else {
-		r.Abort(http.StatusForbidden, "")
+		r.writeStatus(http.StatusForbidden, "")
 	}
 }
 func (r *responseImp) ResponseStatusNotFound(err error) {
 	if err != nil {
-		r.Abort(http.StatusNotFound, err.Error())
+		r.writeStatus(http.StatusNotFound, err.Error())
 	} else {
-		r.Abort(http.StatusNotFound, "")
+		r.writeStatus(http.StatusNotFound, "")
 	}
 }
 func (r *responseImp) ResponseStatusInternalServerError(err error) {
 	if err != nil {
-		r.Abort(http.StatusInternalServerError, err.Error())
+		r.writeStatus(http.StatusInternalServerError, err.Error())
 	} else {
-		r.Abort(http.StatusInternalServerError, "")
-	}
-}
-
-func newResponse(c *Context) *responseImp {
-	return &responseImp{
-		ResponseWriter: c,
-		Hijacker:       c,
-		Flusher:        c,
-		c:              c,
+		r.writeStatus(http.StatusInternalServerError, "")
 	}
 }
 
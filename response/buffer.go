@@ -0,0 +1,74 @@
+package response
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+)
+
+// ErrRenderFail 编码失败，此时还没有输出任何数据，调用者可以输出其他响应（例如500）
+var ErrRenderFail = errors.New("render response fail")
+
+// 超过该大小的缓冲区不放回池中，避免长期占用内存
+const maxPooledBufferSize = 64 << 10
+
+var bufferPool = sync.Pool{
+	New: func() interface{} {
+		return new(bytes.Buffer)
+	},
+}
+
+func getBuffer() *bytes.Buffer {
+	buf := bufferPool.Get().(*bytes.Buffer)
+	buf.Reset()
+	return buf
+}
+
+func putBuffer(buf *bytes.Buffer) {
+	if buf.Cap() <= maxPooledBufferSize {
+		bufferPool.Put(buf)
+	}
+}
+
+// render 先编码到缓冲区，成功后再输出响应头（包括 Content-Length）和数据
+func render(w http.ResponseWriter, code int, contentType string, encode func(*bytes.Buffer) error) error {
+	buf := getBuffer()
+	defer putBuffer(buf)
+
+	if err := encode(buf); err != nil {
+		return fmt.Errorf("%v : %w", err, ErrRenderFail)
+	}
+	return writeBytes(w, code, contentType, buf.Bytes())
+}
+
+// writeBytes 设置 Content-Type/Content-Length 并输出
+func writeBytes(w http.ResponseWriter, code int, contentType string, data []byte) error {
+	if len(contentType) > 0 {
+		writeContentType(w, contentType)
+	}
+	if !bodyAllowedForStatus(code) {
+		w.WriteHeader(code)
+		return nil
+	}
+
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	w.WriteHeader(code)
+	_, err := w.Write(data)
+	return err
+}
+
+// bodyAllowedForStatus 同 net/http
+func bodyAllowedForStatus(status int) bool {
+	switch {
+	case status >= 100 && status <= 199:
+		return false
+	case status == http.StatusNoContent:
+		return false
+	case status == http.StatusNotModified:
+		return false
+	}
+	return true
+}
@@ -7,12 +7,5 @@ package response
 import "net/http"
 
 func WriteData(w http.ResponseWriter, code int, contentType string, data []byte) error {
-	if len(contentType) > 0 {
-		writeContentType(w, contentType)
-	}
-	w.WriteHeader(code)
-	if _, err := w.Write(data); err != nil {
-		return err
-	}
-	return nil
+	return writeBytes(w, code, contentType, data)
 }
@@ -0,0 +1,57 @@
+package response
+
+import (
+	"strings"
+)
+
+// ContentDisposition 生成 Content-Disposition 头 https://www.rfc-editor.org/rfc/rfc6266
+// 非ASCII文件名使用 filename* 编码，同时提供ASCII的 filename 兼容旧客户端
+func ContentDisposition(dispositionType, filename string) string {
+	if filename == "" {
+		return dispositionType
+	}
+
+	ascii := true
+	var fallback strings.Builder
+	for _, r := range filename {
+		switch {
+		case r >= 0x80 || r < 0x20 || r == 0x7f:
+			ascii = false
+			fallback.WriteByte('_')
+		case r == '"' || r == '\\':
+			fallback.WriteByte('_')
+		default:
+			fallback.WriteRune(r)
+		}
+	}
+
+	result := dispositionType + `; filename="` + fallback.String() + `"`
+	if !ascii {
+		result += "; filename*=UTF-8''" + encodeExtValue(filename)
+	}
+	return result
+}
+
+// encodeExtValue 按照 RFC 5987 attr-char 编码
+func encodeExtValue(value string) string {
+	const hex = "0123456789ABCDEF"
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		if isAttrChar(c) {
+			b.WriteByte(c)
+		} else {
+			b.WriteByte('%')
+			b.WriteByte(hex[c>>4])
+			b.WriteByte(hex[c&0x0f])
+		}
+	}
+	return b.String()
+}
+
+func isAttrChar(c byte) bool {
+	if 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' {
+		return true
+	}
+	return strings.IndexByte("!#$&+-.^_`|~", c) >= 0
+}
@@ -5,26 +5,19 @@
 package response
 
 import (
+	"bytes"
 	"html/template"
-	"io"
 	"net/http"
 )
 
 const htmlContentType = "text/html; charset=utf-8"
 
 func WriteHTML(w http.ResponseWriter, code int, data string) error {
-	writeContentType(w, htmlContentType)
-	w.WriteHeader(code)
-
-	if _, err := io.WriteString(w, data); err != nil {
-		return err
-	}
-	return nil
+	return writeBytes(w, code, htmlContentType, []byte(data))
 }
 
 func WriteTemplateHTML(w http.ResponseWriter, code int, temp *template.Template, data interface{}) error {
-	writeContentType(w, htmlContentType)
-	w.WriteHeader(code)
-
-	return temp.Execute(w, data)
+	return render(w, code, htmlContentType, func(buf *bytes.Buffer) error {
+		return temp.Execute(buf, data)
+	})
 }
@@ -5,8 +5,14 @@
 package response
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"regexp"
+	"unicode/utf16"
+	"unicode/utf8"
 )
 
 // bug https://github.com/golang/go/issues/14914
@@ -14,25 +20,101 @@ import (
 const jsonContentType = "application/json; charset=utf-8"
 
 func WriteIndentedJSON(w http.ResponseWriter, code int, obj interface{}) error {
-	writeContentType(w, jsonContentType)
-	jsonBytes, err := json.MarshalIndent(obj, "", "    ")
-	if err != nil {
-		return err
-	}
-	w.WriteHeader(code)
-	w.Write(jsonBytes)
-	return nil
+	return render(w, code, jsonContentType, func(buf *bytes.Buffer) error {
+		encoder := json.NewEncoder(buf)
+		encoder.SetIndent("", "    ")
+		return encoder.Encode(obj)
+	})
 }
 
 func WriteJSON(w http.ResponseWriter, code int, obj interface{}) error {
-	writeContentType(w, jsonContentType)
-	w.WriteHeader(code)
-	return json.NewEncoder(w).Encode(obj)
+	return render(w, code, jsonContentType, func(buf *bytes.Buffer) error {
+		return json.NewEncoder(buf).Encode(obj)
+	})
 }
 
 func WriteRawJSON(w http.ResponseWriter, code int, content []byte) error {
-	writeContentType(w, jsonContentType)
-	w.WriteHeader(code)
-	w.Write(content)
-	return nil
+	return writeBytes(w, code, jsonContentType, content)
+}
+
+const jsonpContentType = "application/javascript; charset=utf-8"
+
+var (
+	// SecureJSONPrefix WriteSecureJSON 在数组前添加的前缀，防止json劫持
+	SecureJSONPrefix = "while(1);"
+	// JSONPCallbackParam JSONP回调函数名的请求参数
+	JSONPCallbackParam = "callback"
+	// ErrInvalidCallback JSONP回调函数名不合法
+	ErrInvalidCallback = errors.New("invalid jsonp callback")
+
+	callbackPattern = regexp.MustCompile(`^[a-zA-Z_$][a-zA-Z0-9_$]*(\.[a-zA-Z_$][a-zA-Z0-9_$]*)*$`)
+)
+
+// ValidCallback JSONP回调函数名是否合法，只允许 a.b_c$ 这种标识符
+func ValidCallback(callback string) bool {
+	return len(callback) <= 128 && callbackPattern.MatchString(callback)
+}
+
+// WriteJSONP 输出 callback(json); callback为空时输出json，不合法时返回 ErrInvalidCallback（不输出）
+func WriteJSONP(w http.ResponseWriter, code int, callback string, obj interface{}) error {
+	if callback == "" {
+		return WriteJSON(w, code, obj)
+	}
+	if !ValidCallback(callback) {
+		return ErrInvalidCallback
+	}
+
+	return render(w, code, jsonpContentType, func(buf *bytes.Buffer) error {
+		jsonBytes, err := json.Marshal(obj)
+		if err != nil {
+			return err
+		}
+		// 注释前缀避免 Rosetta Flash 攻击
+		fmt.Fprintf(buf, "/**/%s(%s);", callback, jsonBytes)
+		return nil
+	})
+}
+
+// WriteSecureJSON 数组前添加 SecureJSONPrefix
+func WriteSecureJSON(w http.ResponseWriter, code int, obj interface{}) error {
+	return render(w, code, jsonContentType, func(buf *bytes.Buffer) error {
+		jsonBytes, err := json.Marshal(obj)
+		if err != nil {
+			return err
+		}
+		if bytes.HasPrefix(jsonBytes, []byte("[")) {
+			buf.WriteString(SecureJSONPrefix)
+		}
+		buf.Write(jsonBytes)
+		return nil
+	})
+}
+
+// WriteASCIIJSON 非ASCII字符转义为 \uXXXX
+func WriteASCIIJSON(w http.ResponseWriter, code int, obj interface{}) error {
+	return render(w, code, jsonContentType, func(buf *bytes.Buffer) error {
+		jsonBytes, err := json.Marshal(obj)
+		if err != nil {
+			return err
+		}
+		for _, r := range string(jsonBytes) {
+			if r < utf8.RuneSelf {
+				buf.WriteByte(byte(r))
+			} else if r1, r2 := utf16.EncodeRune(r); r1 != utf8.RuneError {
+				fmt.Fprintf(buf, `\u%04x\u%04x`, r1, r2)
+			} else {
+				fmt.Fprintf(buf, `\u%04x`, r)
+			}
+		}
+		return nil
+	})
+}
+
+// WritePureJSON 不转义html字符 <>&
+func WritePureJSON(w http.ResponseWriter, code int, obj interface{}) error {
+	return render(w, code, jsonContentType, func(buf *bytes.Buffer) error {
+		encoder := json.NewEncoder(buf)
+		encoder.SetEscapeHTML(false)
+		return encoder.Encode(obj)
+	})
 }
@@ -0,0 +1,19 @@
+package response
+
+import (
+	"bytes"
+	"net/http"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+const msgpackContentType = "application/msgpack"
+
+// WriteMsgPack 没有msgpack tag的字段使用json tag，和json共用结构体定义
+func WriteMsgPack(w http.ResponseWriter, code int, obj interface{}) error {
+	return render(w, code, msgpackContentType, func(buf *bytes.Buffer) error {
+		encoder := msgpack.NewEncoder(buf)
+		encoder.SetCustomStructTag("json")
+		return encoder.Encode(obj)
+	})
+}
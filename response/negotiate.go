@@ -0,0 +1,193 @@
+package response
+
+import (
+	"fmt"
+	"html"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	MIMEJSON  = "application/json"
+	MIMEXML   = "application/xml"
+	MIMEXML2  = "text/xml"
+	MIMEYAML  = "application/yaml"
+	MIMEYAML2 = "application/x-yaml"
+	MIMEPlain = "text/plain"
+	MIMEHTML  = "text/html"
+	MIMEJSONP = "application/javascript"
+
+	MIMEMSGPACK  = "application/msgpack"
+	MIMEMSGPACK2 = "application/x-msgpack"
+	MIMEPROTOBUF = "application/x-protobuf"
+
+	// 以下offer的mime类型都是json，通过参数区分输出方式
+	OfferPureJSON   = "application/json; render=pure"
+	OfferASCIIJSON  = "application/json; render=ascii"
+	OfferSecureJSON = "application/json; render=secure"
+)
+
+// Renderer 按照某种格式输出data，r用于读取请求参数（例如JSONP的callback）
+type Renderer func(w http.ResponseWriter, r *http.Request, code int, data interface{}) error
+
+// renderer 忽略请求的Renderer
+func renderer(write func(http.ResponseWriter, int, interface{}) error) Renderer {
+	return func(w http.ResponseWriter, r *http.Request, code int, data interface{}) error {
+		return write(w, code, data)
+	}
+}
+
+var (
+	renderers  = map[string]Renderer{}
+	renderLock sync.RWMutex
+)
+
+func init() {
+	Register(MIMEJSON, renderer(WriteJSON))
+	Register(MIMEXML, renderer(WriteXML))
+	Register(MIMEXML2, renderer(WriteXML))
+	Register(MIMEYAML, renderer(WriteYAML))
+	Register(MIMEYAML2, renderer(WriteYAML))
+	Register(MIMEPlain, renderer(func(w http.ResponseWriter, code int, data interface{}) error {
+		return WriteString(w, code, fmt.Sprint(data), nil)
+	}))
+	Register(MIMEHTML, renderer(func(w http.ResponseWriter, code int, data interface{}) error {
+		// 没有模板时按文本输出，转义避免XSS
+		return WriteHTML(w, code, html.EscapeString(fmt.Sprint(data)))
+	}))
+	Register(MIMEMSGPACK, renderer(WriteMsgPack))
+	Register(MIMEMSGPACK2, renderer(WriteMsgPack))
+	Register(MIMEPROTOBUF, renderer(WriteProtoBuf))
+	Register(MIMEJSONP, RenderJSONP)
+	Register(OfferPureJSON, renderer(WritePureJSON))
+	Register(OfferASCIIJSON, renderer(WriteASCIIJSON))
+	Register(OfferSecureJSON, renderer(WriteSecureJSON))
+}
+
+// RenderJSONP 从请求参数中读取回调函数名，不合法时返回400
+func RenderJSONP(w http.ResponseWriter, r *http.Request, code int, data interface{}) error {
+	err := WriteJSONP(w, code, r.URL.Query().Get(JSONPCallbackParam), data)
+	if err == ErrInvalidCallback {
+		return WriteString(w, http.StatusBadRequest, err.Error(), nil)
+	}
+	return err
+}
+
+// Register 注册（或者替换）Renderer，用于内容协商
+// key为mime类型，或者带参数的offer（例如 OfferPureJSON），查找时优先匹配完整的offer
+func Register(offer string, renderer Renderer) {
+	renderLock.Lock()
+	defer renderLock.Unlock()
+	renderers[normalizeOffer(offer)] = renderer
+}
+
+// Lookup 获得offer对应的Renderer，没有时使用offer的mime类型查找
+func Lookup(offer string) (Renderer, bool) {
+	renderLock.RLock()
+	defer renderLock.RUnlock()
+	if renderer, ok := renderers[normalizeOffer(offer)]; ok {
+		return renderer, true
+	}
+	mediaType, _, err := mime.ParseMediaType(offer)
+	if err != nil {
+		return nil, false
+	}
+	renderer, ok := renderers[mediaType]
+	return renderer, ok
+}
+
+func normalizeOffer(offer string) string {
+	mediaType, params, err := mime.ParseMediaType(offer)
+	if err != nil {
+		return strings.ToLower(offer)
+	}
+	return mime.FormatMediaType(mediaType, params)
+}
+
+// AcceptRange Accept请求头中的一项 eg. text/html;q=0.8
+type AcceptRange struct {
+	Type    string // eg. text
+	SubType string // eg. html
+	Q       float64
+}
+
+// specificity 越具体优先级越高 */* < text/* < text/html
+func (a *AcceptRange) specificity() int {
+	if a.Type == "*" {
+		return 0
+	}
+	if a.SubType == "*" {
+		return 1
+	}
+	return 2
+}
+
+func (a *AcceptRange) match(mediaType string) bool {
+	typ, subType := splitMediaType(mediaType)
+	return (a.Type == "*" || a.Type == typ) && (a.SubType == "*" || a.SubType == subType)
+}
+
+func splitMediaType(mediaType string) (string, string) {
+	if i := strings.IndexByte(mediaType, '/'); i >= 0 {
+		return mediaType[:i], mediaType[i+1:]
+	}
+	return mediaType, "*"
+}
+
+// ParseAccept 解析Accept请求头，按q值从高到低排序，忽略格式错误的项
+func ParseAccept(accept string) []AcceptRange {
+	var ranges []AcceptRange
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if value, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(value, 64); err != nil || q < 0 || q > 1 {
+				continue
+			}
+		}
+		typ, subType := splitMediaType(mediaType)
+		ranges = append(ranges, AcceptRange{Type: typ, SubType: subType, Q: q})
+	}
+	sort.SliceStable(ranges, func(i, j int) bool {
+		return ranges[i].Q > ranges[j].Q
+	})
+	return ranges
+}
+
+// Negotiate 根据Accept请求头从offers中选择最合适的mime类型（offers中的参数被忽略）
+// q值相同时优先使用offers中靠前的，没有匹配时返回空字符串
+func Negotiate(accept string, offers []string) string {
+	if len(offers) == 0 {
+		return ""
+	}
+	if strings.TrimSpace(accept) == "" {
+		return offers[0]
+	}
+
+	ranges := ParseAccept(accept)
+	best, bestQ := "", 0.0
+	for _, offer := range offers {
+		mediaType, _, err := mime.ParseMediaType(offer)
+		if err != nil {
+			continue
+		}
+		// 以最具体的匹配项的q值为准
+		q, specificity := 0.0, -1
+		for i := range ranges {
+			if ranges[i].match(mediaType) && ranges[i].specificity() > specificity {
+				q, specificity = ranges[i].Q, ranges[i].specificity()
+			}
+		}
+		if q > bestQ {
+			best, bestQ = offer, q
+		}
+	}
+	return best
+}
@@ -0,0 +1,29 @@
+package response
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+
+	"google.golang.org/protobuf/proto"
+)
+
+const protobufContentType = "application/x-protobuf"
+
+// ErrNotProtoMessage 输出protobuf的对象必须实现 proto.Message
+var ErrNotProtoMessage = errors.New("object is not a proto.Message")
+
+func WriteProtoBuf(w http.ResponseWriter, code int, obj interface{}) error {
+	return render(w, code, protobufContentType, func(buf *bytes.Buffer) error {
+		msg, ok := obj.(proto.Message)
+		if !ok {
+			return ErrNotProtoMessage
+		}
+		data, err := proto.Marshal(msg)
+		if err != nil {
+			return err
+		}
+		buf.Write(data)
+		return nil
+	})
+}
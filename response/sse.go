@@ -0,0 +1,89 @@
+package response
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const sseContentType = "text/event-stream"
+
+// 字段值不能包含换行
+var fieldReplacer = strings.NewReplacer("\r", "", "\n", "")
+
+// Event Server-Sent Events 的一个事件
+// https://html.spec.whatwg.org/multipage/server-sent-events.html
+type Event struct {
+	ID    string      // 事件ID，客户端重连时通过 Last-Event-ID 请求头带回
+	Event string      // 事件名称，为空时客户端触发message事件
+	Retry uint        // 客户端重连间隔（毫秒），0 表示不设置
+	Data  interface{} // string/[]byte 原样输出，其他类型编码为json
+}
+
+// WriteSSEHeader 设置SSE响应头，需要在输出事件之前调用
+func WriteSSEHeader(w http.ResponseWriter) {
+	header := w.Header()
+	header.Set("Content-Type", sseContentType)
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Connection", "keep-alive")
+	// 禁止nginx缓冲
+	header.Set("X-Accel-Buffering", "no")
+}
+
+// WriteEvent 按照SSE格式输出一个事件
+func WriteEvent(w io.Writer, event *Event) error {
+	var buf bytes.Buffer
+	if event.ID != "" {
+		writeField(&buf, "id", event.ID)
+	}
+	if event.Event != "" {
+		writeField(&buf, "event", event.Event)
+	}
+	if event.Retry > 0 {
+		writeField(&buf, "retry", strconv.FormatUint(uint64(event.Retry), 10))
+	}
+	if event.Data != nil {
+		data, err := encodeEventData(event.Data)
+		if err != nil {
+			return err
+		}
+		// 多行数据每行一个data字段
+		for _, line := range strings.Split(data, "\n") {
+			writeField(&buf, "data", line)
+		}
+	}
+	buf.WriteByte('\n')
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// WriteComment 输出注释，客户端会忽略，常用于心跳
+func WriteComment(w io.Writer, comment string) error {
+	_, err := io.WriteString(w, ": "+strings.ReplaceAll(comment, "\n", " ")+"\n\n")
+	return err
+}
+
+func writeField(buf *bytes.Buffer, name, value string) {
+	buf.WriteString(name)
+	buf.WriteString(": ")
+	buf.WriteString(fieldReplacer.Replace(value))
+	buf.WriteByte('\n')
+}
+
+func encodeEventData(data interface{}) (string, error) {
+	switch value := data.(type) {
+	case string:
+		return strings.ReplaceAll(value, "\r\n", "\n"), nil
+	case []byte:
+		return strings.ReplaceAll(string(value), "\r\n", "\n"), nil
+	default:
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return "", err
+		}
+		return string(encoded), nil
+	}
+}
@@ -5,25 +5,20 @@
 package response
 
 import (
+	"bytes"
 	"fmt"
-	"io"
 	"net/http"
 )
 
 const plainContentType = "text/plain; charset=utf-8"
 
 func WriteString(w http.ResponseWriter, code int, format string, data []interface{}) error {
-	writeContentType(w, plainContentType)
-	w.WriteHeader(code)
-
-	if len(data) > 0 {
-		if _, err := fmt.Fprintf(w, format, data...); err != nil {
-			return err
-		}
-	} else {
-		if _, err := io.WriteString(w, format); err != nil {
-			return err
+	return render(w, code, plainContentType, func(buf *bytes.Buffer) error {
+		if len(data) > 0 {
+			fmt.Fprintf(buf, format, data...)
+		} else {
+			buf.WriteString(format)
 		}
-	}
-	return nil
+		return nil
+	})
 }
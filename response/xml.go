@@ -5,6 +5,7 @@
 package response
 
 import (
+	"bytes"
 	"encoding/xml"
 	"net/http"
 )
@@ -12,8 +13,7 @@ import (
 var xmlContentType = "application/xml; charset=utf-8"
 
 func WriteXML(w http.ResponseWriter, code int, data interface{}) error {
-	writeContentType(w, xmlContentType)
-	w.WriteHeader(code)
-
-	return xml.NewEncoder(w).Encode(data)
+	return render(w, code, xmlContentType, func(buf *bytes.Buffer) error {
+		return xml.NewEncoder(buf).Encode(data)
+	})
 }
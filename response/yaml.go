@@ -0,0 +1,20 @@
+package response
+
+import (
+	"bytes"
+	"net/http"
+
+	"gopkg.in/yaml.v3"
+)
+
+const yamlContentType = "application/yaml; charset=utf-8"
+
+func WriteYAML(w http.ResponseWriter, code int, obj interface{}) error {
+	return render(w, code, yamlContentType, func(buf *bytes.Buffer) error {
+		encoder := yaml.NewEncoder(buf)
+		if err := encoder.Encode(obj); err != nil {
+			return err
+		}
+		return encoder.Close()
+	})
+}
@@ -7,10 +7,12 @@ import (
 	"errors"
 	"html/template"
 	"net/http"
+	"strconv"
 	"strings"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
+	responseBackend "github.com/lixinio/kelly/response"
 )
 
 func TestHandleResp(t *testing.T) {
@@ -384,3 +386,91 @@ func TestHandleMisc(t *testing.T) {
 	}, http.StatusInternalServerError, "ResponseStatusInternalServerError")
 
 }
+
+func TestWriteJSONVariants(t *testing.T) {
+	data := H{"html": "<b>&</b>", "name": "凯利😀"}
+	testcases := []struct {
+		path        string
+		handler     HandlerFunc
+		code        int
+		contentType string
+		body        string
+	}{
+		{"/?callback=app.cb", func(c *Context) { c.WriteJSONP(http.StatusOK, H{"a": 1}) },
+			http.StatusOK, "application/javascript", `/**/app.cb({"a":1});`},
+		{"/", func(c *Context) { c.WriteJSONP(http.StatusOK, H{"a": 1}) },
+			http.StatusOK, "application/json", `{"a":1}`},
+		{"/?callback=alert(1)", func(c *Context) { c.WriteJSONP(http.StatusOK, H{"a": 1}) },
+			http.StatusBadRequest, "text/plain", "invalid jsonp callback"},
+		{"/", func(c *Context) { c.WriteSecureJSON(http.StatusOK, []int{1, 2}) },
+			http.StatusOK, "application/json", `while(1);[1,2]`},
+		{"/", func(c *Context) { c.WriteSecureJSON(http.StatusOK, H{"a": 1}) },
+			http.StatusOK, "application/json", `{"a":1}`},
+		{"/", func(c *Context) { c.WriteASCIIJSON(http.StatusOK, data) },
+			http.StatusOK, "application/json", `{"html":"\u003cb\u003e\u0026\u003c/b\u003e","name":"\u51ef\u5229\ud83d\ude00"}`},
+		{"/", func(c *Context) { c.WritePureJSON(http.StatusOK, data) },
+			http.StatusOK, "application/json", `{"html":"<b>&</b>","name":"凯利😀"}`},
+	}
+	for _, testcase := range testcases {
+		resp := getFramwork(testcase.handler, testcase.path, nil, nil, "")
+		body := readBody(resp)
+		if resp.StatusCode != testcase.code || readContentType(resp) != testcase.contentType || body != testcase.body {
+			t.Errorf("write json fail %s|%d|%s|%s", testcase.path, resp.StatusCode, readContentType(resp), body)
+		}
+	}
+
+	// 内容协商
+	negotiate := func(c *Context) {
+		c.Negotiate(http.StatusOK, []string{"<a>"}, "application/javascript", responseBackend.OfferSecureJSON)
+	}
+	resp := getFramwork(negotiate, "/?callback=cb", nil, map[string]string{"Accept": "application/javascript"}, "")
+	if body := readBody(resp); body != `/**/cb(["\u003ca\u003e"]);` {
+		t.Errorf("negotiate jsonp fail %s", body)
+	}
+	resp = getFramwork(negotiate, "/", nil, map[string]string{"Accept": "application/json"}, "")
+	if body := readBody(resp); body != `while(1);["\u003ca\u003e"]` {
+		t.Errorf("negotiate secure json fail %s", body)
+	}
+}
+
+func TestRenderFail(t *testing.T) {
+	var handled error
+	k := New(&Config{
+		DisableBanner: true,
+		Logger:        NewTextLogger(&bytes.Buffer{}, LevelInfo),
+		HandleError: func(c *Context, err error) {
+			handled = err
+			c.WriteString(http.StatusInternalServerError, "render fail")
+		},
+	})
+	k.GET("/fail", func(c *Context) {
+		c.WriteJSON(http.StatusOK, map[string]interface{}{"ch": make(chan int)})
+	})
+	k.GET("/ok", func(c *Context) {
+		c.WriteJSON(http.StatusOK, map[string]string{"k": "v"})
+	})
+
+	r, _ := http.NewRequest(http.MethodGet, "/fail", nil)
+	resp := k.RunTest(r)
+	if resp.StatusCode != http.StatusInternalServerError || readBody(resp) != "render fail" {
+		t.Errorf("render fail not handled %d", resp.StatusCode)
+	}
+	if !errors.Is(handled, responseBackend.ErrRenderFail) {
+		t.Errorf("unexpected error %v", handled)
+	}
+
+	r, _ = http.NewRequest(http.MethodGet, "/ok", nil)
+	resp = k.RunTest(r)
+	body := readRawBody(resp)
+	if resp.Header.Get("Content-Length") != strconv.Itoa(len(body)) {
+		t.Errorf("content length %s != %d", resp.Header.Get("Content-Length"), len(body))
+	}
+
+	// 缺省处理返回500，不输出部分数据
+	resp = getFramwork(func(c *Context) {
+		c.WriteXML(http.StatusOK, map[string]string{"k": "v"})
+	}, "/", map[string]string{}, map[string]string{}, "")
+	if resp.StatusCode != http.StatusInternalServerError || readContentType(resp) != "application/json" {
+		t.Errorf("default render fail handler %d %s", resp.StatusCode, readContentType(resp))
+	}
+}
@@ -0,0 +1,49 @@
+package kelly
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestResponseWriter(t *testing.T) {
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodGet, "/", nil)
+	c := newContext(w, r)
+
+	if c.Written() || c.Status() != http.StatusOK || c.Size() != 0 {
+		t.Errorf("init response writer fail %v|%d|%d", c.Written(), c.Status(), c.Size())
+	}
+
+	c.WriteString(http.StatusCreated, "abcde")
+	// 重复设置响应码无效
+	c.WriteHeader(http.StatusInternalServerError)
+	if !c.Written() || c.Status() != http.StatusCreated || c.Size() != 5 {
+		t.Errorf("response writer fail %v|%d|%d", c.Written(), c.Status(), c.Size())
+	}
+	if w.Code != http.StatusCreated {
+		t.Errorf("response code fail %d", w.Code)
+	}
+
+	// 透传可选接口
+	if _, ok := c.ResponseWriter.(http.Flusher); !ok {
+		t.Errorf("response writer is not a Flusher")
+	}
+	c.ResponseWriter.(http.Flusher).Flush()
+	if !w.Flushed {
+		t.Errorf("flush fail")
+	}
+	if err := c.ResponseWriter.(http.Pusher).Push("/a", nil); err != http.ErrNotSupported {
+		t.Errorf("push fail %v", err)
+	}
+	if _, _, err := c.ResponseWriter.(http.Hijacker).Hijack(); err != ErrNotHijacker {
+		t.Errorf("hijack fail %v", err)
+	}
+
+	n, err := c.ResponseWriter.(io.ReaderFrom).ReadFrom(strings.NewReader("fgh"))
+	if err != nil || n != 3 || c.Size() != 8 || w.Body.String() != "abcdefgh" {
+		t.Errorf("read from fail %d|%v|%d|%s", n, err, c.Size(), w.Body.String())
+	}
+}
@@ -0,0 +1,94 @@
+package kelly
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	responseBackend "github.com/lixinio/kelly/response"
+)
+
+// ServerSentEvent SSE事件
+type ServerSentEvent = responseBackend.Event
+
+// HeaderLastEventID 客户端重连时带回的最后一个事件ID
+const HeaderLastEventID = "Last-Event-ID"
+
+const defaultSSEHeartbeat = 15 * time.Second
+
+type SSEConfig struct {
+	Retry     time.Duration // 建议客户端的重连间隔，0 表示不设置
+	Heartbeat time.Duration // 心跳间隔，缺省15秒，小于0表示不发送心跳
+}
+
+// LastEventID 获得客户端重连时带回的最后一个事件ID，用于断点续传
+func (c *Context) LastEventID() string {
+	return c.r.Header.Get(HeaderLastEventID)
+}
+
+// SSEvent 输出一个SSE事件并立即Flush
+func (c *Context) SSEvent(name string, data interface{}) {
+	if err := c.writeEvent(&ServerSentEvent{Event: name, Data: data}); c.resp.failed(err) {
+		panic(fmt.Errorf("write event fail, : %w(%s)", ErrWriteRespFail, err))
+	}
+}
+
+func (c *Context) writeEvent(event *ServerSentEvent) error {
+	if !c.Written() {
+		responseBackend.WriteSSEHeader(c)
+	}
+	if err := responseBackend.WriteEvent(c, event); err != nil {
+		return err
+	}
+	c.Flush()
+	return nil
+}
+
+// SSE 以SSE方式持续输出events中的事件，定期发送心跳
+// events关闭或者客户端断开（c.Context().Done()）时结束，返回值表示客户端是否已经断开
+func (c *Context) SSE(config *SSEConfig, events <-chan *ServerSentEvent) bool {
+	if config == nil {
+		config = &SSEConfig{}
+	}
+	heartbeat := config.Heartbeat
+	if heartbeat == 0 {
+		heartbeat = defaultSSEHeartbeat
+	}
+
+	responseBackend.WriteSSEHeader(c)
+	c.WriteHeader(http.StatusOK)
+	c.Flush()
+	if config.Retry > 0 {
+		if err := c.writeEvent(&ServerSentEvent{Retry: uint(config.Retry / time.Millisecond)}); err != nil {
+			return true
+		}
+	}
+
+	var tick <-chan time.Time
+	if heartbeat > 0 {
+		ticker := time.NewTicker(heartbeat)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	done := c.Context().Done()
+	for {
+		select {
+		case <-done:
+			return true
+		case event, ok := <-events:
+			if !ok {
+				return false
+			}
+			if err := c.writeEvent(event); err != nil {
+				// 写失败一般是客户端已经断开
+				return true
+			}
+		case <-tick:
+			if err := responseBackend.WriteComment(c, "heartbeat"); err != nil {
+				return true
+			}
+			c.Flush()
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package kelly
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSSEvent(t *testing.T) {
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set(HeaderLastEventID, "41")
+	c := newContext(w, r)
+	if c.LastEventID() != "41" {
+		t.Errorf("last event id fail %s", c.LastEventID())
+	}
+	c.SSEvent("progress", "line1\nline2")
+	c.SSEvent("", H{"percent": 50})
+
+	expect := "event: progress\ndata: line1\ndata: line2\n\n" + "data: {\"percent\":50}\n\n"
+	if w.Body.String() != expect || !w.Flushed {
+		t.Errorf("sse event fail %q", w.Body.String())
+	}
+	if w.Header().Get("Content-Type") != "text/event-stream" || w.Header().Get("Cache-Control") != "no-cache" {
+		t.Errorf("sse header fail %v", w.Header())
+	}
+}
+
+func TestSSE(t *testing.T) {
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodGet, "/", nil)
+	c := newContext(w, r)
+
+	events := make(chan *ServerSentEvent)
+	go func() {
+		events <- &ServerSentEvent{ID: "42", Event: "tick", Data: "1"}
+		// 等待心跳
+		time.Sleep(30 * time.Millisecond)
+		close(events)
+	}()
+	clientGone := c.SSE(&SSEConfig{Retry: 3 * time.Second, Heartbeat: 10 * time.Millisecond}, events)
+
+	body := w.Body.String()
+	if clientGone ||
+		!strings.HasPrefix(body, "retry: 3000\n\nid: 42\nevent: tick\ndata: 1\n\n") ||
+		!strings.Contains(body, ": heartbeat\n\n") {
+		t.Errorf("sse fail %v|%q", clientGone, body)
+	}
+
+	// 客户端断开
+	ctx, cancel := context.WithCancel(context.Background())
+	c = newContext(httptest.NewRecorder(), r.WithContext(ctx))
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+	if clientGone = c.SSE(nil, make(chan *ServerSentEvent)); !clientGone {
+		t.Errorf("sse cancel fail")
+	}
+}
@@ -0,0 +1,33 @@
+package kelly
+
+import (
+	"net/http"
+
+	"github.com/lixinio/kelly/websocket"
+)
+
+var (
+	// UpgraderKey websocket配置在容器中的key，可以通过 Kelly.Provide 或者 Context.Set 设置
+	UpgraderKey = NewKey[*websocket.Upgrader]("kelly.websocket.upgrader")
+	// OriginAllowedKey 跨域中间件已经明确允许了Origin（不包括允许所有Origin），Upgrade 不再校验
+	OriginAllowedKey = NewKey[bool]("kelly.origin.allowed")
+
+	defaultUpgrader = &websocket.Upgrader{}
+)
+
+// Upgrade 升级为websocket连接，握手失败时已经输出了错误响应
+// 连接需要在handler返回前使用完毕（Context会被回收），期间可以正常访问path变量、当前用户等数据
+func (c *Context) Upgrade() (*websocket.Conn, error) {
+	upgrader, _ := UpgraderKey.Get(c)
+	if upgrader == nil {
+		upgrader = defaultUpgrader
+	}
+	if allowed, _ := OriginAllowedKey.Get(c); allowed {
+		copied := *upgrader
+		copied.CheckOrigin = func(*http.Request) bool {
+			return true
+		}
+		upgrader = &copied
+	}
+	return upgrader.Upgrade(c, c.r, nil)
+}
@@ -0,0 +1,80 @@
+package kelly
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestUpgrade(t *testing.T) {
+	k := New(&Config{DisableBanner: true})
+	k.GET("/ws/:id",
+		func(c *Context) {
+			c.SetUser(NewPrincipal("test", "alice", nil))
+			if c.Request().Header.Get("Origin") == "http://allowed.com" {
+				// 模拟跨域中间件
+				c.Set(OriginAllowedKey, true)
+			}
+			c.InvokeNext()
+		},
+		func(c *Context) {
+			conn, err := c.Upgrade()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+			_, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			conn.WriteText(string(data) + " " + c.MustGetPathVarible("id") + " " + c.User().Identity())
+		},
+	)
+	srv := httptest.NewServer(k)
+	defer srv.Close()
+
+	handshake := func(origin string) (net.Conn, *bufio.Reader, *http.Response) {
+		conn, err := net.Dial("tcp", srv.Listener.Addr().String())
+		if err != nil {
+			t.Fatalf("dial fail %v", err)
+		}
+		r, _ := http.NewRequest(http.MethodGet, srv.URL+"/ws/42", nil)
+		r.Header.Set("Connection", "Upgrade")
+		r.Header.Set("Upgrade", "websocket")
+		r.Header.Set("Sec-WebSocket-Version", "13")
+		r.Header.Set("Sec-WebSocket-Key", "dGhlIHNhbXBsZSBub25jZQ==")
+		r.Header.Set("Origin", origin)
+		r.Write(conn)
+		br := bufio.NewReader(conn)
+		resp, err := http.ReadResponse(br, r)
+		if err != nil {
+			t.Fatalf("handshake fail %v", err)
+		}
+		return conn, br, resp
+	}
+
+	conn, _, resp := handshake("http://evil.com")
+	conn.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("upgrade origin check fail %d", resp.StatusCode)
+	}
+
+	conn, br, resp := handshake("http://allowed.com")
+	defer conn.Close()
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("upgrade fail %d", resp.StatusCode)
+	}
+	// 客户端帧必须带掩码，掩码全0时数据不变
+	conn.Write([]byte{0x81, 0x80 | 2, 0, 0, 0, 0, 'h', 'i'})
+	header := make([]byte, 2)
+	io.ReadFull(br, header)
+	body := make([]byte, header[1])
+	io.ReadFull(br, body)
+	if header[0] != 0x81 || !strings.HasPrefix(string(body), "hi 42 alice") {
+		t.Errorf("upgrade message fail %v|%s", header, body)
+	}
+}
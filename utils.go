@@ -1,5 +1,14 @@
 package kelly
 
+import (
+	"reflect"
+	"runtime"
+)
+
+func nameOfFunction(f interface{}) string {
+	return runtime.FuncForPC(reflect.ValueOf(f).Pointer()).Name()
+}
+
 func filterFlags(content string) string {
 	for i, char := range content {
 		if char == ' ' || char == ';' {
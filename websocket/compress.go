@@ -0,0 +1,93 @@
+package websocket
+
+import (
+	"bytes"
+	"compress/flate"
+	"io"
+	"strings"
+	"sync"
+)
+
+// permessage-deflate https://www.rfc-editor.org/rfc/rfc7692
+// 双方都不保留上下文（no_context_takeover），每个消息单独压缩
+const (
+	deflateExtension = "permessage-deflate"
+	deflateResponse  = "permessage-deflate; server_no_context_takeover; client_no_context_takeover"
+)
+
+// 压缩数据的结尾，发送时去掉，接收时补上
+var deflateTail = []byte{0x00, 0x00, 0xff, 0xff, 0x01, 0x00, 0x00, 0xff, 0xff}
+
+var flateWriterPools [flate.BestCompression - flate.HuffmanOnly + 1]sync.Pool
+
+func compress(data []byte, level int) ([]byte, error) {
+	pool := &flateWriterPools[level-flate.HuffmanOnly]
+	var buf bytes.Buffer
+	fw, _ := pool.Get().(*flate.Writer)
+	if fw == nil {
+		var err error
+		if fw, err = flate.NewWriter(&buf, level); err != nil {
+			return nil, err
+		}
+	} else {
+		fw.Reset(&buf)
+	}
+	defer pool.Put(fw)
+
+	if _, err := fw.Write(data); err != nil {
+		return nil, err
+	}
+	if err := fw.Flush(); err != nil {
+		return nil, err
+	}
+	// 去掉 sync flush 产生的 00 00 ff ff
+	return buf.Bytes()[:buf.Len()-4], nil
+}
+
+// decompress 解压，结果超过limit时返回 errMessageTooBig
+func decompress(data []byte, limit int64) ([]byte, error) {
+	fr := flate.NewReader(io.MultiReader(bytes.NewReader(data), bytes.NewReader(deflateTail)))
+	defer fr.Close()
+
+	result, err := io.ReadAll(io.LimitReader(fr, limit+1))
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return nil, err
+	}
+	if int64(len(result)) > limit {
+		return nil, errMessageTooBig
+	}
+	return result, nil
+}
+
+// acceptDeflate 客户端是否提供了可以接受的permessage-deflate参数
+func acceptDeflate(extensions []string) bool {
+	for _, header := range extensions {
+		for _, offer := range strings.Split(header, ",") {
+			params := strings.Split(offer, ";")
+			if strings.TrimSpace(params[0]) != deflateExtension {
+				continue
+			}
+			if deflateParamsSupported(params[1:]) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func deflateParamsSupported(params []string) bool {
+	for _, param := range params {
+		name, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+		switch strings.TrimSpace(name) {
+		case "server_no_context_takeover", "client_no_context_takeover", "client_max_window_bits":
+		case "server_max_window_bits":
+			// compress/flate 固定使用32K窗口
+			if strings.Trim(strings.TrimSpace(value), `"`) != "15" {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,425 @@
+package websocket
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// 消息类型（opcode）
+const (
+	TextMessage   = 1
+	BinaryMessage = 2
+	CloseMessage  = 8
+	PingMessage   = 9
+	PongMessage   = 10
+
+	continuationFrame = 0
+)
+
+const (
+	finalBit = 1 << 7
+	rsv1Bit  = 1 << 6
+	rsv2Bit  = 1 << 5
+	rsv3Bit  = 1 << 4
+	maskBit  = 1 << 7
+
+	maxControlFramePayloadSize = 125
+	maxFrameHeaderSize         = 2 + 8 + 4
+)
+
+var errMessageTooBig = errors.New("websocket message too big")
+
+// Conn RFC 6455 连接
+// 读写可以分别在两个goroutine中进行；多个goroutine同时写是安全的，但只能有一个goroutine读
+type Conn struct {
+	conn           net.Conn
+	br             *bufio.Reader
+	isServer       bool
+	subprotocol    string
+	maxMessageSize int64
+	compression    bool // 协商了permessage-deflate
+	compressLevel  int
+
+	writeMu   sync.Mutex
+	closeSent bool
+
+	readErr     error
+	pingHandler func(data string) error
+	pongHandler func(data string) error
+}
+
+func newConn(conn net.Conn, br *bufio.Reader, isServer bool, maxMessageSize int64) *Conn {
+	if br == nil {
+		br = bufio.NewReader(conn)
+	}
+	c := &Conn{
+		conn:           conn,
+		br:             br,
+		isServer:       isServer,
+		maxMessageSize: maxMessageSize,
+	}
+	c.pingHandler = c.defaultPingHandler
+	return c
+}
+
+// Subprotocol 协商的子协议
+func (c *Conn) Subprotocol() string {
+	return c.subprotocol
+}
+
+// Compression 是否启用了permessage-deflate
+func (c *Conn) Compression() bool {
+	return c.compression
+}
+
+func (c *Conn) LocalAddr() net.Addr {
+	return c.conn.LocalAddr()
+}
+
+func (c *Conn) RemoteAddr() net.Addr {
+	return c.conn.RemoteAddr()
+}
+
+// UnderlyingConn 底层的net.Conn
+func (c *Conn) UnderlyingConn() net.Conn {
+	return c.conn
+}
+
+func (c *Conn) SetReadDeadline(t time.Time) error {
+	return c.conn.SetReadDeadline(t)
+}
+
+func (c *Conn) SetWriteDeadline(t time.Time) error {
+	return c.conn.SetWriteDeadline(t)
+}
+
+// SetReadLimit 设置消息（解压后）的最大字节数，超过时以1009关闭连接，0 表示不限制
+func (c *Conn) SetReadLimit(limit int64) {
+	c.maxMessageSize = limit
+}
+
+// SetPingHandler 设置收到ping时的回调，缺省回复pong
+func (c *Conn) SetPingHandler(h func(data string) error) {
+	if h == nil {
+		h = c.defaultPingHandler
+	}
+	c.pingHandler = h
+}
+
+// SetPongHandler 设置收到pong时的回调，常用于延长读超时
+func (c *Conn) SetPongHandler(h func(data string) error) {
+	c.pongHandler = h
+}
+
+func (c *Conn) defaultPingHandler(data string) error {
+	err := c.WriteControl(PongMessage, []byte(data))
+	if err == ErrCloseSent {
+		return nil
+	}
+	return err
+}
+
+// ReadMessage 读取一个完整的消息（合并分片、解压），期间自动处理ping/pong/close
+// 收到close帧时回复close，并返回 *CloseError
+func (c *Conn) ReadMessage() (int, []byte, error) {
+	if c.readErr != nil {
+		return 0, nil, c.readErr
+	}
+
+	messageType, data, err := c.readMessage()
+	if err != nil {
+		c.readErr = err
+		return 0, nil, err
+	}
+	return messageType, data, nil
+}
+
+func (c *Conn) readMessage() (int, []byte, error) {
+	var (
+		messageType int
+		compressed  bool
+		data        []byte
+	)
+
+	for {
+		b0, err := c.br.ReadByte()
+		if err != nil {
+			return 0, nil, err
+		}
+		b1, err := c.br.ReadByte()
+		if err != nil {
+			return 0, nil, err
+		}
+
+		final := b0&finalBit != 0
+		opcode := int(b0 & 0x0f)
+		rsv1 := b0&rsv1Bit != 0
+		masked := b1&maskBit != 0
+
+		if b0&(rsv2Bit|rsv3Bit) != 0 || (rsv1 && (!c.compression || opcode == continuationFrame || isControl(opcode))) {
+			return 0, nil, c.fail(CloseProtocolError, "unexpected reserved bits")
+		}
+		// 客户端发送的帧必须有掩码，服务端发送的帧不能有掩码
+		if masked != c.isServer {
+			return 0, nil, c.fail(CloseProtocolError, "invalid mask bit")
+		}
+
+		length := int64(b1 & 0x7f)
+		switch length {
+		case 126:
+			var buf [2]byte
+			if _, err := io.ReadFull(c.br, buf[:]); err != nil {
+				return 0, nil, err
+			}
+			length = int64(binary.BigEndian.Uint16(buf[:]))
+		case 127:
+			var buf [8]byte
+			if _, err := io.ReadFull(c.br, buf[:]); err != nil {
+				return 0, nil, err
+			}
+			length = int64(binary.BigEndian.Uint64(buf[:]))
+			if length < 0 {
+				return 0, nil, c.fail(CloseProtocolError, "invalid payload length")
+			}
+		}
+
+		var maskKey [4]byte
+		if masked {
+			if _, err := io.ReadFull(c.br, maskKey[:]); err != nil {
+				return 0, nil, err
+			}
+		}
+
+		switch {
+		case isControl(opcode):
+			if !final || length > maxControlFramePayloadSize {
+				return 0, nil, c.fail(CloseProtocolError, "invalid control frame")
+			}
+		case opcode == continuationFrame:
+			if messageType == 0 {
+				return 0, nil, c.fail(CloseProtocolError, "unexpected continuation frame")
+			}
+		case opcode == TextMessage || opcode == BinaryMessage:
+			if messageType != 0 {
+				return 0, nil, c.fail(CloseProtocolError, "expect continuation frame")
+			}
+			messageType, compressed = opcode, rsv1
+		default:
+			return 0, nil, c.fail(CloseProtocolError, "unknown opcode")
+		}
+
+		if !isControl(opcode) && c.maxMessageSize > 0 && int64(len(data))+length > c.maxMessageSize {
+			return 0, nil, c.fail(CloseMessageTooBig, "message too big")
+		}
+
+		payload := make([]byte, length)
+		if _, err := io.ReadFull(c.br, payload); err != nil {
+			return 0, nil, err
+		}
+		if masked {
+			maskBytes(maskKey, payload)
+		}
+
+		if isControl(opcode) {
+			if err := c.handleControl(opcode, payload); err != nil {
+				return 0, nil, err
+			}
+			continue
+		}
+
+		data = append(data, payload...)
+		if final {
+			break
+		}
+	}
+
+	if compressed {
+		limit := c.maxMessageSize
+		if limit <= 0 {
+			limit = 1<<63 - 2
+		}
+		decompressed, err := decompress(data, limit)
+		if err == errMessageTooBig {
+			return 0, nil, c.fail(CloseMessageTooBig, "message too big")
+		} else if err != nil {
+			return 0, nil, c.fail(CloseProtocolError, "invalid compressed data")
+		}
+		data = decompressed
+	}
+	if messageType == TextMessage && !utf8.Valid(data) {
+		return 0, nil, c.fail(CloseInvalidFramePayloadData, "invalid utf8 text")
+	}
+	return messageType, data, nil
+}
+
+func (c *Conn) handleControl(opcode int, payload []byte) error {
+	switch opcode {
+	case PingMessage:
+		return c.pingHandler(string(payload))
+	case PongMessage:
+		if c.pongHandler != nil {
+			return c.pongHandler(string(payload))
+		}
+		return nil
+	}
+
+	// close
+	closeErr := &CloseError{Code: CloseNoStatusReceived}
+	if len(payload) == 1 {
+		return c.fail(CloseProtocolError, "invalid close payload")
+	}
+	if len(payload) >= 2 {
+		closeErr.Code = int(binary.BigEndian.Uint16(payload))
+		closeErr.Text = string(payload[2:])
+		if !validCloseCode(closeErr.Code) || !utf8.Valid(payload[2:]) {
+			return c.fail(CloseProtocolError, "invalid close code")
+		}
+	}
+	// 回复close，完成关闭握手
+	reply := []byte{}
+	if closeErr.Code != CloseNoStatusReceived {
+		reply = FormatCloseMessage(closeErr.Code, "")
+	}
+	if err := c.WriteControl(CloseMessage, reply); err != nil && err != ErrCloseSent {
+		return err
+	}
+	return closeErr
+}
+
+// fail 协议错误，发送close帧并返回错误
+func (c *Conn) fail(code int, text string) error {
+	c.WriteControl(CloseMessage, FormatCloseMessage(code, text))
+	return &CloseError{Code: code, Text: text}
+}
+
+// WriteMessage 输出一个文本或者二进制消息，也可以输出控制帧（同 WriteControl）
+func (c *Conn) WriteMessage(messageType int, data []byte) error {
+	if isControl(messageType) {
+		return c.WriteControl(messageType, data)
+	}
+	if messageType != TextMessage && messageType != BinaryMessage {
+		return ErrInvalidMessageType
+	}
+
+	var rsv byte
+	if c.compression {
+		compressed, err := compress(data, c.compressLevel)
+		if err != nil {
+			return err
+		}
+		data = compressed
+		rsv = rsv1Bit
+	}
+	return c.writeFrame(byte(messageType)|rsv, data)
+}
+
+// WriteText 输出文本消息
+func (c *Conn) WriteText(text string) error {
+	return c.WriteMessage(TextMessage, []byte(text))
+}
+
+// WriteControl 输出控制帧（ping/pong/close），payload不能超过125字节
+func (c *Conn) WriteControl(messageType int, data []byte) error {
+	if !isControl(messageType) {
+		return ErrInvalidMessageType
+	}
+	if len(data) > maxControlFramePayloadSize {
+		return ErrInvalidControlFrame
+	}
+	return c.writeFrame(byte(messageType), data)
+}
+
+// WriteClose 发送close帧，之后不能再输出，应继续读取直到收到对端的close
+func (c *Conn) WriteClose(code int, text string) error {
+	return c.WriteControl(CloseMessage, FormatCloseMessage(code, text))
+}
+
+// Close 发送close帧（若还没有发送）并关闭底层连接
+func (c *Conn) Close() error {
+	c.WriteClose(CloseNormalClosure, "")
+	return c.conn.Close()
+}
+
+func (c *Conn) writeFrame(b0 byte, payload []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	if c.closeSent {
+		return ErrCloseSent
+	}
+	if int(b0&0x0f) == CloseMessage {
+		c.closeSent = true
+	}
+
+	header := make([]byte, maxFrameHeaderSize)
+	header[0] = b0 | finalBit
+	length := len(payload)
+	switch {
+	case length <= 125:
+		header[1] = byte(length)
+		header = header[:2]
+	case length <= 0xffff:
+		header[1] = 126
+		binary.BigEndian.PutUint16(header[2:], uint16(length))
+		header = header[:4]
+	default:
+		header[1] = 127
+		binary.BigEndian.PutUint64(header[2:], uint64(length))
+		header = header[:10]
+	}
+
+	if !c.isServer {
+		// 客户端需要对数据加掩码
+		var maskKey [4]byte
+		if _, err := rand.Read(maskKey[:]); err != nil {
+			return err
+		}
+		header[1] |= maskBit
+		header = append(header, maskKey[:]...)
+		masked := make([]byte, length)
+		copy(masked, payload)
+		maskBytes(maskKey, masked)
+		payload = masked
+	}
+
+	buffers := net.Buffers{header, payload}
+	_, err := buffers.WriteTo(c.conn)
+	return err
+}
+
+// FormatCloseMessage 生成close帧的payload
+func FormatCloseMessage(code int, text string) []byte {
+	if code == CloseNoStatusReceived {
+		return []byte{}
+	}
+	buf := make([]byte, 2, 2+len(text))
+	binary.BigEndian.PutUint16(buf, uint16(code))
+	return append(buf, text...)
+}
+
+func isControl(opcode int) bool {
+	return opcode >= CloseMessage
+}
+
+func validCloseCode(code int) bool {
+	switch {
+	case code >= 1000 && code <= 1003, code >= 1007 && code <= 1011:
+		return true
+	case code >= 3000 && code <= 4999:
+		return true
+	}
+	return false
+}
+
+func maskBytes(key [4]byte, data []byte) {
+	for i := range data {
+		data[i] ^= key[i&3]
+	}
+}
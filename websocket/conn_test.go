@@ -0,0 +1,193 @@
+package websocket
+
+import (
+	"bufio"
+	"bytes"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// dial 测试用的客户端，完成握手后复用Conn（客户端模式）
+func dial(t *testing.T, srv *httptest.Server, header http.Header) (*Conn, *http.Response) {
+	netConn, err := net.Dial("tcp", srv.Listener.Addr().String())
+	require.Nil(t, err)
+
+	r, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+	r.Header.Set("Connection", "Upgrade")
+	r.Header.Set("Upgrade", "websocket")
+	r.Header.Set("Sec-WebSocket-Version", "13")
+	r.Header.Set("Sec-WebSocket-Key", "dGhlIHNhbXBsZSBub25jZQ==")
+	for name, values := range header {
+		r.Header[name] = values
+	}
+	require.Nil(t, r.Write(netConn))
+
+	br := bufio.NewReader(netConn)
+	resp, err := http.ReadResponse(br, r)
+	require.Nil(t, err)
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		netConn.Close()
+		return nil, resp
+	}
+	conn := newConn(netConn, br, false, 0)
+	conn.compression = strings.HasPrefix(resp.Header.Get("Sec-Websocket-Extensions"), deflateExtension)
+	conn.compressLevel = 1
+	return conn, resp
+}
+
+func echoServer(t *testing.T, upgrader *Upgrader) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for {
+			messageType, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			if err := conn.WriteMessage(messageType, data); err != nil {
+				return
+			}
+		}
+	}))
+}
+
+func TestConn(t *testing.T) {
+	srv := echoServer(t, &Upgrader{Subprotocols: []string{"chat"}, MaxMessageSize: 1024})
+	defer srv.Close()
+
+	conn, resp := dial(t, srv, http.Header{"Sec-Websocket-Protocol": {"foo, chat"}})
+	require.Equal(t, "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=", resp.Header.Get("Sec-Websocket-Accept"))
+	require.Equal(t, "chat", resp.Header.Get("Sec-Websocket-Protocol"))
+
+	require.Nil(t, conn.WriteText("hello"))
+	messageType, data, err := conn.ReadMessage()
+	require.Nil(t, err)
+	require.Equal(t, TextMessage, messageType)
+	require.Equal(t, "hello", string(data))
+
+	// 服务端自动回复pong
+	pong := make(chan string, 1)
+	conn.SetPongHandler(func(data string) error {
+		pong <- data
+		return nil
+	})
+	require.Nil(t, conn.WriteControl(PingMessage, []byte("ping")))
+	require.Nil(t, conn.WriteMessage(BinaryMessage, []byte{1, 2, 3}))
+	messageType, data, err = conn.ReadMessage()
+	require.Nil(t, err)
+	require.Equal(t, BinaryMessage, messageType)
+	require.Equal(t, []byte{1, 2, 3}, data)
+	require.Equal(t, "ping", <-pong)
+
+	// 分片消息
+	require.Nil(t, conn.writeFrameRaw(TextMessage, false, []byte("hel")))
+	require.Nil(t, conn.WriteControl(PingMessage, nil))
+	require.Nil(t, conn.writeFrameRaw(continuationFrame, true, []byte("lo")))
+	_, data, err = conn.ReadMessage()
+	require.Nil(t, err)
+	require.Equal(t, "hello", string(data))
+
+	// 关闭握手
+	require.Nil(t, conn.WriteClose(CloseNormalClosure, "bye"))
+	_, _, err = conn.ReadMessage()
+	require.True(t, IsCloseError(err, CloseNormalClosure))
+	require.Equal(t, ErrCloseSent, conn.WriteText("x"))
+	conn.UnderlyingConn().Close()
+
+	// 超过最大消息长度
+	conn, _ = dial(t, srv, nil)
+	require.Nil(t, conn.WriteMessage(BinaryMessage, make([]byte, 2048)))
+	_, _, err = conn.ReadMessage()
+	require.True(t, IsCloseError(err, CloseMessageTooBig))
+	conn.UnderlyingConn().Close()
+
+	// 非法utf8
+	conn, _ = dial(t, srv, nil)
+	require.Nil(t, conn.WriteMessage(TextMessage, []byte{0xff, 0xfe}))
+	_, _, err = conn.ReadMessage()
+	require.True(t, IsCloseError(err, CloseInvalidFramePayloadData))
+	conn.UnderlyingConn().Close()
+}
+
+func TestConnCompression(t *testing.T) {
+	srv := echoServer(t, &Upgrader{EnableCompression: true, MaxMessageSize: 4096})
+	defer srv.Close()
+
+	conn, resp := dial(t, srv, http.Header{
+		"Sec-Websocket-Extensions": {"permessage-deflate; client_max_window_bits"},
+	})
+	defer conn.Close()
+	require.Equal(t, deflateResponse, resp.Header.Get("Sec-Websocket-Extensions"))
+	require.True(t, conn.Compression())
+
+	message := bytes.Repeat([]byte("kelly "), 500)
+	for i := 0; i < 2; i++ {
+		require.Nil(t, conn.WriteMessage(TextMessage, message))
+		_, data, err := conn.ReadMessage()
+		require.Nil(t, err)
+		require.Equal(t, message, data)
+	}
+
+	// 压缩后很小，但解压后超过限制
+	require.Nil(t, conn.WriteMessage(TextMessage, bytes.Repeat([]byte("k"), 8192)))
+	_, _, err := conn.ReadMessage()
+	require.True(t, IsCloseError(err, CloseMessageTooBig))
+
+	// 不支持的参数
+	require.False(t, acceptDeflate([]string{"permessage-deflate; server_max_window_bits=10"}))
+	require.True(t, acceptDeflate([]string{"x-webkit-deflate-frame, permessage-deflate; server_max_window_bits=15"}))
+}
+
+func TestCompressionLevel(t *testing.T) {
+	// 不合法的压缩级别使用缺省值
+	for _, level := range []int{10, -3} {
+		srv := echoServer(t, &Upgrader{EnableCompression: true, CompressionLevel: level})
+		conn, _ := dial(t, srv, http.Header{"Sec-Websocket-Extensions": {"permessage-deflate"}})
+		require.Nil(t, conn.WriteMessage(TextMessage, []byte("kelly")))
+		_, data, err := conn.ReadMessage()
+		require.Nil(t, err)
+		require.Equal(t, "kelly", string(data))
+		conn.Close()
+		srv.Close()
+	}
+}
+
+func TestUpgradeFail(t *testing.T) {
+	srv := echoServer(t, &Upgrader{})
+	defer srv.Close()
+
+	_, resp := dial(t, srv, http.Header{"Sec-Websocket-Version": {"8"}})
+	require.Equal(t, http.StatusUpgradeRequired, resp.StatusCode)
+	_, resp = dial(t, srv, http.Header{"Sec-Websocket-Key": {"short"}})
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	_, resp = dial(t, srv, http.Header{"Origin": {"http://evil.com"}})
+	require.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	resp, err := http.Get(srv.URL)
+	require.Nil(t, err)
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+// writeFrameRaw 测试分片：输出一个不带FIN的帧
+func (c *Conn) writeFrameRaw(opcode int, final bool, payload []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	b0 := byte(opcode)
+	if final {
+		b0 |= finalBit
+	}
+	var maskKey [4]byte
+	masked := append([]byte{}, payload...)
+	maskBytes(maskKey, masked)
+	header := []byte{b0, maskBit | byte(len(payload)), 0, 0, 0, 0}
+	_, err := c.conn.Write(append(header, masked...))
+	return err
+}
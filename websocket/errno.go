@@ -0,0 +1,60 @@
+package websocket
+
+import (
+	"errors"
+	"strconv"
+)
+
+var (
+	// ErrBadHandshake 握手请求不合法
+	ErrBadHandshake = errors.New("websocket bad handshake")
+	// ErrOriginNotAllowed Origin校验失败
+	ErrOriginNotAllowed = errors.New("websocket origin not allowed")
+	// ErrNotHijacker 不支持接管连接
+	ErrNotHijacker = errors.New("websocket response writer is not a http.Hijacker")
+	// ErrCloseSent 已经发送了close帧，不能再输出
+	ErrCloseSent = errors.New("websocket close sent")
+	// ErrInvalidControlFrame 控制帧不合法（超过125字节）
+	ErrInvalidControlFrame = errors.New("websocket invalid control frame")
+	// ErrInvalidMessageType 错误的消息类型
+	ErrInvalidMessageType = errors.New("websocket invalid message type")
+)
+
+// 关闭码 https://www.rfc-editor.org/rfc/rfc6455#section-7.4.1
+const (
+	CloseNormalClosure           = 1000
+	CloseGoingAway               = 1001
+	CloseProtocolError           = 1002
+	CloseUnsupportedData         = 1003
+	CloseNoStatusReceived        = 1005
+	CloseAbnormalClosure         = 1006
+	CloseInvalidFramePayloadData = 1007
+	ClosePolicyViolation         = 1008
+	CloseMessageTooBig           = 1009
+	CloseMandatoryExtension      = 1010
+	CloseInternalServerErr       = 1011
+)
+
+// CloseError 收到对端的close帧，或者因为协议错误关闭连接
+type CloseError struct {
+	Code int
+	Text string
+}
+
+func (e *CloseError) Error() string {
+	return "websocket close " + strconv.Itoa(e.Code) + " " + e.Text
+}
+
+// IsCloseError err是否为指定关闭码的CloseError
+func IsCloseError(err error, codes ...int) bool {
+	var closeErr *CloseError
+	if !errors.As(err, &closeErr) {
+		return false
+	}
+	for _, code := range codes {
+		if closeErr.Code == code {
+			return true
+		}
+	}
+	return false
+}
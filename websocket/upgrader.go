@@ -0,0 +1,180 @@
+package websocket
+
+import (
+	"compress/flate"
+	"crypto/sha1"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	acceptGUID            = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+	defaultMaxMessageSize = 32 << 20
+)
+
+// Upgrader 将http请求升级为websocket连接
+type Upgrader struct {
+	HandshakeTimeout  time.Duration              // 输出握手响应的超时，0 表示不限制
+	MaxMessageSize    int64                      // 消息的最大字节数，缺省32M，小于0表示不限制
+	EnableCompression bool                       // 客户端支持时启用 permessage-deflate
+	CompressionLevel  int                        // 压缩级别 flate.HuffmanOnly ~ flate.BestCompression，缺省（或者不合法时） flate.BestSpeed
+	Subprotocols      []string                   // 服务端支持的子协议，按优先级排序
+	CheckOrigin       func(r *http.Request) bool // Origin校验，缺省要求和Host一致
+}
+
+// Upgrade 校验握手请求，接管连接并返回websocket连接
+// 握手失败时已经输出了错误响应
+func (u *Upgrader) Upgrade(w http.ResponseWriter, r *http.Request, responseHeader http.Header) (*Conn, error) {
+	if r.Method != http.MethodGet {
+		return u.fail(w, http.StatusMethodNotAllowed, "request method is not GET")
+	}
+	if !headerContains(r.Header, "Connection", "upgrade") {
+		return u.fail(w, http.StatusBadRequest, "'upgrade' token not found in 'Connection' header")
+	}
+	if !headerContains(r.Header, "Upgrade", "websocket") {
+		return u.fail(w, http.StatusBadRequest, "'websocket' token not found in 'Upgrade' header")
+	}
+	if r.Header.Get("Sec-Websocket-Version") != "13" {
+		w.Header().Set("Sec-WebSocket-Version", "13")
+		return u.fail(w, http.StatusUpgradeRequired, "unsupported version")
+	}
+	key := r.Header.Get("Sec-Websocket-Key")
+	if decoded, err := base64.StdEncoding.DecodeString(key); err != nil || len(decoded) != 16 {
+		return u.fail(w, http.StatusBadRequest, "invalid 'Sec-WebSocket-Key' header")
+	}
+
+	checkOrigin := u.CheckOrigin
+	if checkOrigin == nil {
+		checkOrigin = SameOrigin
+	}
+	if !checkOrigin(r) {
+		http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		return nil, ErrOriginNotAllowed
+	}
+
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		return u.fail(w, http.StatusInternalServerError, "response does not implement http.Hijacker")
+	}
+
+	subprotocol := u.selectSubprotocol(r)
+	compression := u.EnableCompression && acceptDeflate(r.Header.Values("Sec-Websocket-Extensions"))
+
+	netConn, brw, err := hijacker.Hijack()
+	if err != nil {
+		return nil, err
+	}
+
+	var b strings.Builder
+	b.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Accept: ")
+	b.WriteString(acceptKey(key))
+	b.WriteString("\r\n")
+	if subprotocol != "" {
+		b.WriteString("Sec-WebSocket-Protocol: " + subprotocol + "\r\n")
+	}
+	if compression {
+		b.WriteString("Sec-WebSocket-Extensions: " + deflateResponse + "\r\n")
+	}
+	for name, values := range responseHeader {
+		if name == "Sec-Websocket-Protocol" || name == "Sec-Websocket-Extensions" {
+			continue
+		}
+		for _, value := range values {
+			b.WriteString(name + ": " + value + "\r\n")
+		}
+	}
+	b.WriteString("\r\n")
+
+	if u.HandshakeTimeout > 0 {
+		netConn.SetWriteDeadline(time.Now().Add(u.HandshakeTimeout))
+	}
+	if _, err := netConn.Write([]byte(b.String())); err != nil {
+		netConn.Close()
+		return nil, err
+	}
+	if u.HandshakeTimeout > 0 {
+		netConn.SetWriteDeadline(time.Time{})
+	}
+
+	maxMessageSize := u.MaxMessageSize
+	if maxMessageSize == 0 {
+		maxMessageSize = defaultMaxMessageSize
+	} else if maxMessageSize < 0 {
+		maxMessageSize = 0
+	}
+	conn := newConn(netConn, brw.Reader, true, maxMessageSize)
+	conn.subprotocol = subprotocol
+	conn.compression = compression
+	conn.compressLevel = u.CompressionLevel
+	if conn.compressLevel == 0 || conn.compressLevel < flate.HuffmanOnly || conn.compressLevel > flate.BestCompression {
+		// 不合法的级别使用缺省值
+		conn.compressLevel = flate.BestSpeed
+	}
+	return conn, nil
+}
+
+func (u *Upgrader) fail(w http.ResponseWriter, code int, reason string) (*Conn, error) {
+	http.Error(w, http.StatusText(code), code)
+	return nil, fmt.Errorf("%s : %w", reason, ErrBadHandshake)
+}
+
+func (u *Upgrader) selectSubprotocol(r *http.Request) string {
+	requested := headerTokens(r.Header, "Sec-Websocket-Protocol")
+	for _, supported := range u.Subprotocols {
+		for _, protocol := range requested {
+			if protocol == supported {
+				return protocol
+			}
+		}
+	}
+	return ""
+}
+
+// IsWebSocketUpgrade 请求是否为websocket握手
+func IsWebSocketUpgrade(r *http.Request) bool {
+	return headerContains(r.Header, "Connection", "upgrade") && headerContains(r.Header, "Upgrade", "websocket")
+}
+
+// SameOrigin 缺省的Origin校验，没有Origin请求头或者Origin和Host一致时通过
+func SameOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(u.Host, r.Host)
+}
+
+func acceptKey(key string) string {
+	h := sha1.New()
+	h.Write([]byte(key + acceptGUID))
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+func headerTokens(header http.Header, name string) []string {
+	var tokens []string
+	for _, value := range header.Values(name) {
+		for _, token := range strings.Split(value, ",") {
+			if token = strings.TrimSpace(token); token != "" {
+				tokens = append(tokens, token)
+			}
+		}
+	}
+	return tokens
+}
+
+func headerContains(header http.Header, name, token string) bool {
+	for _, t := range headerTokens(header, name) {
+		if strings.EqualFold(t, token) {
+			return true
+		}
+	}
+	return false
+}